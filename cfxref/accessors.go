@@ -0,0 +1,137 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Regular expression for a property declared in a script component
+var regexpScriptProp = regexp.MustCompile(`(?i)^\s*property\s+([^;{]*)`)
+
+// Property declared with cfproperty or the script property statement
+type propertyDef struct {
+	name     string // Name of the property
+	kind     string // Type of the property
+	fileName string // Full name of the file declaring the property
+	line     int    // Line the property is declared on
+	getter   bool   // An implicit getter is generated
+	setter   bool   // An implicit setter is generated
+}
+
+// Properties of each component by component key
+var properties = make(map[string][]propertyDef, 1000)
+
+// recordProperty Capture a property declared at the component level
+// scope: Function currently being scanned
+// fileName: Full name of the file being scanned
+// lineNo: Line number in the file
+// code: Text of the line with the comments removed
+// scriptMode: The line is script rather than tags
+func recordProperty(scope funcScope, fileName string, lineNo int, code string, scriptMode bool) {
+	if len(scope.name) > 0 {
+		return
+	}
+
+	var attrs map[string]string
+
+	if scriptMode {
+		match := regexpScriptProp.FindStringSubmatch(code)
+
+		if match == nil {
+			return
+		}
+
+		attrs = parseAttributes(match[1])
+
+		// The short form is property [type] name
+		words := strings.Fields(regexpAttr.ReplaceAllString(match[1], ""))
+
+		if len(attrs["name"]) == 0 && len(words) > 0 {
+			attrs["name"] = words[len(words)-1]
+
+			if len(words) > 1 && len(attrs["type"]) == 0 {
+				attrs["type"] = words[len(words)-2]
+			}
+		}
+	} else {
+		start := indexTag(strings.ToLower(code), "<cfproperty")
+
+		if start < 0 {
+			return
+		}
+
+		attrs = parseAttributes(tagText(code, start))
+	}
+
+	if len(attrs["name"]) == 0 {
+		return
+	}
+
+	mapName := strings.ToLower(removeSuffix(relativeName(fileName)))
+	properties[mapName] = append(properties[mapName], propertyDef{name: attrs["name"], kind: attrs["type"], fileName: fileName, line: lineNo,
+		getter: !isFalse(attrs["getter"]), setter: !isFalse(attrs["setter"])})
+}
+
+// isFalse Check for a boolean attribute that is turned off
+func isFalse(value string) bool {
+	return strings.EqualFold(value, "false") || strings.EqualFold(value, "no")
+}
+
+// isTrue Check for a boolean attribute that is turned on
+func isTrue(value string) bool {
+	return strings.EqualFold(value, "true") || strings.EqualFold(value, "yes")
+}
+
+// synthesizeAccessors Add the implicit getters and setters of the components using accessors.
+// Methods that are defined explicitly take precedence over the implicit ones
+func synthesizeAccessors() {
+	for mapName, declared := range properties {
+		attrs := componentAttrs[mapName]
+
+		// Persistent (ORM) components always have accessors
+		if !isTrue(attrs["accessors"]) && !isTrue(attrs["persistent"]) {
+			continue
+		}
+
+		component, found := xref[mapName]
+
+		if !found {
+			// A component may only have properties
+			fileName := declared[0].fileName
+			component = compDef{name: removeSuffix(relativeName(fileName)), fileName: fileName,
+				funcs: make(map[string]funcDef)}
+			xref[mapName] = component
+		}
+
+		for _, property := range declared {
+			// The accessor names capitalize the property name, i.e. getFirstName
+			suffix := strings.ToUpper(property.name[:1]) + property.name[1:]
+
+			if property.getter {
+				addAccessor(component, property, "get"+suffix, property.kind, nil)
+			}
+
+			if property.setter {
+				args := []map[string]string{{"name": property.name, "type": property.kind, "required": "true"}}
+				addAccessor(component, property, "set"+suffix, "void", args)
+			}
+		}
+	}
+}
+
+// addAccessor Add an implicit accessor to a component unless the method is already defined
+func addAccessor(component compDef, property propertyDef, funcName string, returnType string, args []map[string]string) {
+	funcKey := strings.ToLower(funcName)
+
+	if _, found := component.funcs[funcKey]; found {
+		return
+	}
+
+	attrs := map[string]string{"access": "public", "returntype": returnType,
+		"hint": "Implicit accessor for the property " + property.name}
+
+	component.funcs[funcKey] = funcDef{name: funcName, line: property.line, endLine: property.line, implicit: true,
+		attrs: attrs, args: args, usedBy: make(map[string]funcUsage)}
+
+	totalFuncs++
+}
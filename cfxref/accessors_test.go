@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSynthesizeAccessors(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+	useRoot(t, dir)
+	enterRoot(roots[0])
+	useXref(t)
+
+	savedProperties := properties
+	defer func() { properties = savedProperties }()
+	properties = make(map[string][]propertyDef)
+
+	files := map[string]string{
+		"Person.cfc": "<cfcomponent accessors=\"true\">\n" +
+			"<cfproperty name=\"firstName\" type=\"string\">\n" +
+			"<cfproperty name=\"id\" type=\"numeric\" getter=\"false\">\n" +
+			"<cfproperty name=\"age\" type=\"numeric\" setter=\"false\">\n" +
+			"<cffunction name=\"getAge\" access=\"public\" returntype=\"string\"></cffunction>\n" +
+			"</cfcomponent>\n",
+		"Plain.cfc":  "<cfcomponent>\n<cfproperty name=\"title\">\n</cfcomponent>\n",
+		"Entity.cfc": "<cfcomponent persistent=\"true\">\n<cfproperty name=\"title\" type=\"string\">\n</cfcomponent>\n",
+	}
+
+	for name, content := range files {
+		fileName := filepath.Join(dir, name)
+		a.NoError(os.WriteFile(fileName, []byte(content), 0644))
+		a.NoError(parseFile(fileName))
+	}
+
+	synthesizeAccessors()
+
+	// A property has a getter and a setter taking its type
+	person := xref["/person"].funcs
+	if a.Contains(person, "getfirstname") && a.Contains(person, "setfirstname") {
+		a.True(person["getfirstname"].implicit)
+		a.Equal("getFirstName", person["getfirstname"].name)
+		a.Equal("string", person["getfirstname"].attrs["returntype"])
+		a.Equal([]map[string]string{{"name": "firstName", "type": "string", "required": "true"}}, person["setfirstname"].args)
+	}
+
+	// The accessors can be turned off
+	a.NotContains(person, "getid")
+	a.Contains(person, "setid")
+	a.NotContains(person, "setage")
+
+	// The explicit method wins over the implicit one
+	if a.Contains(person, "getage") {
+		a.False(person["getage"].implicit)
+		a.Equal("string", person["getage"].attrs["returntype"])
+	}
+
+	// Only components with accessors or persistent ones have them
+	a.NotContains(xref["/plain"].funcs, "gettitle")
+	a.Contains(xref["/entity"].funcs, "gettitle")
+	a.Contains(xref["/entity"].funcs, "settitle")
+}
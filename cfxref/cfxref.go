@@ -2,25 +2,37 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // Configuration JSON constants
 const (
-	KwRoot     = "webroot"
-	KwPaths    = "paths"
-	KwVars     = "vars"
-	KwExcludes = "exclude"
-	KwSkipDirs = "skipdirs"
-	KwSave     = "save"
-	KwVerbose  = "verbose"
+	KwRoot       = "webroot"
+	KwPaths      = "paths"
+	KwVars       = "vars"
+	KwExcludes   = "exclude"
+	KwSkipDirs   = "skipdirs"
+	KwSave       = "save"
+	KwVerbose    = "verbose"
+	KwSQLAllow   = "sqlallow"
+	KwComment    = "commented"
+	KwRemove     = "removeorphans"
+	KwDocs       = "docs"
+	KwGit        = "git"
+	KwIndexSkp   = "indexskipped"
+	KwWorkspace  = "workspace"
+	KwCycles     = "cycles"
+	KwFrameworks = "frameworks"
+	KwEncodings  = "encodings"
 )
 
 // Regular expression to isolate cffunction (with name=) and cfinvoke (with component= and method=)
@@ -34,16 +46,39 @@ var regexpFName = regexp.MustCompile(`^[a-zA-Z].*\.([Cc][Ff][Cc]|[Cc][Ff][Mm])$`
 // Regular expression to extract the variable names in between hashes
 var findVars = regexp.MustCompile(`(#[^#]*#)`)
 
+// Regular expression for a function definition in cfscript (optional access and return type), which may follow
+// other code on the line such as the <cfscript> tag or the end of the previous function
+var regexpScriptFunc = regexp.MustCompile(`(?i)(?:^|[\s;{}>])(?:[\w.\[\]]+\s+){0,2}function\s+(\w+)\s*\(`)
+
 // Component definitions
 type compDef struct {
-	name  string             // Full name of the component (without lower case)
-	funcs map[string]funcDef // Function definitions
+	name           string             // Full name of the component (without lower case)
+	fileName       string             // Full path of the file defining the component
+	funcs          map[string]funcDef // Function definitions
+	definitionOnly bool               // Indexed from a skipped directory only to resolve calls
 }
 
 // Function definition
 type funcDef struct {
-	name   string               // Name for this function
-	usedBy map[string]funcUsage // Where this function is called from
+	name     string               // Name for this function
+	line     int                  // Line the function definition starts on
+	endLine  int                  // Line the function definition ends on
+	script   bool                 // The function is written in cfscript
+	attrs    map[string]string    // Attributes of the function such as access and hint
+	args     []map[string]string  // Attributes of each argument
+	usedBy   map[string]funcUsage // Where this function is called from
+	implicit bool                 // Accessor generated from a cfproperty
+	provider string               // Usage provider calling the function, i.e. a framework calling handlers by convention
+}
+
+// Function currently being scanned, used to find where it ends
+type funcScope struct {
+	mapName string // Component key in the xref
+	funcKey string // Function key in the component
+	name    string // Name of the function
+	script  bool   // A script function that ends with its closing brace
+	depth   int    // Brace depth for a script function
+	opened  bool   // The opening brace of a script function has been seen
 }
 
 // Usage data
@@ -58,6 +93,11 @@ type defInvoke struct {
 	line      int    // Line number the cfinvoke occurred on
 	component string // Name of the component being invoked
 	method    string // Name of the method in the component
+	kind      int    // Kind of call (cfinvoke, object method or invoke function)
+	column    int    // Position of the method name in the line
+	dynamic   bool   // The component or method is only known at run time
+	viaObject bool   // Resolved from the type of an object variable
+	remote    bool   // Made from JavaScript, only counted for cfajaxproxy objects
 }
 
 // Collection of invoke information for processing after all cffunctions have been found
@@ -69,10 +109,15 @@ var xref = make(map[string]compDef, 1000)
 // Collect orphans by component and method
 var orphans map[string][]string = make(map[string][]string) // Collect orphan functions by component
 
+// Missing references by the file they are in and the component (and method) they reference
+var missingRefs map[string]interface{} = make(map[string]interface{})
+
 // Output directions
-var orphanWriter *os.File = os.Stderr  // Default orphan output
-var missingWriter *os.File = os.Stderr // Default Misssing output
-var logWriter *os.File = os.Stdout     // Log output file
+var orphanWriter *os.File = os.Stderr    // Default orphan output
+var missingWriter *os.File = os.Stderr   // Default Misssing output
+var logWriter *os.File = os.Stdout       // Log output file
+var sqlWriter *os.File = os.Stderr       // Default SQL lint output
+var commentedWriter *os.File = os.Stderr // Default commented out invoke output
 
 // Buffer for file scanning
 var scanBuff = make([]byte, 5000000)
@@ -90,17 +135,26 @@ var skipDirs map[string]interface{} = make(map[string]interface{}) // Directorie
 var verboseMode bool = false                                       // Verbose output setting
 var crossRefNames []string = make([]string, 0)                     // Array of names to produce a cross reference for
 var crossRefAll bool = false                                       // Flag to indicate to generate a cross ref for everything
+var sqlAllow []*regexp.Regexp = make([]*regexp.Regexp, 0)          // Variable expressions known to be safe in a cfquery
+var listCommented bool = false                                     // List cfinvoke calls that are commented out
 
 // Counters
 var missingFuncCt int = 0   // Number of missing functions
 var missingMethodCt int = 0 // Number of missing methods
 var orphanCompCt int = 0    // Number of orphan components
 var orphanFuncCt int = 0    // Number of orphan functions
+var sqlLintCt int = 0       // Number of possible SQL injection points
 
 // For debugging - current line from the scanner
 var currentLine string
 
 func main() {
+	os.Exit(run())
+}
+
+// run Build the cross reference and produce the reports
+// returns the exit status, once the output files are closed
+func run() int {
 	timeStart := time.Now().Unix()
 	fmt.Fprintln(os.Stdout, "Beginning build process")
 
@@ -109,7 +163,7 @@ func main() {
 	if parseErrs != nil {
 		fmt.Fprintln(os.Stderr, parseErrs)
 		pgmUsage()
-		os.Exit(2)
+		return 2
 	}
 
 	// Close files that are not system output when done
@@ -125,24 +179,108 @@ func main() {
 		defer logWriter.Close()
 	}
 
+	if sqlWriter != os.Stderr {
+		defer sqlWriter.Close()
+	}
+
+	if commentedWriter != os.Stderr {
+		defer commentedWriter.Close()
+	}
+
+	if cycleWriter != os.Stderr {
+		defer cycleWriter.Close()
+	}
+
 	// Process the file structure recursively
-	err := filepath.Walk(rootDir, walkTree)
+	err := walkRoots(walkTree)
+
+	if err == nil {
+		err = processSkipped()
+	}
 
 	if err != nil {
 		fmt.Fprintln(logWriter, err)
-		os.Exit(2)
+		return 2
 	}
 
 	timeBuild := time.Now().Unix()
 	fmt.Fprintln(os.Stdout, "Beginning analysis and reporting")
 
-	// Process list of deferred cfinvoke calls
+	// Restrict the reports to the files changed in the revision range
+	if len(gitRange) > 0 {
+		if err = loadChangedFiles(); err != nil {
+			fmt.Fprintln(logWriter, err)
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	// Add the implicit accessors before the calls are resolved
+	synthesizeAccessors()
+	processProviders()
+
+	// Process list of deferred cfinvoke and script calls
+	resolveScriptCalls()
 	processInvoke()
 
+	// Rename a method instead of reporting
+	if len(renameTarget) > 0 {
+		if err = renameMethod(); err != nil {
+			fmt.Fprintln(logWriter, err)
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		fmt.Fprintln(os.Stdout, "Rename completed successfully")
+		return 0
+	}
+
+	// Show the impact of changes instead of reporting
+	if len(impactNames) > 0 {
+		if err = impactAnalysis(); err != nil {
+			fmt.Fprintln(logWriter, err)
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		return 0
+	}
+
 	// Find list of orphan components/methods
 	processOrphans()
+
+	if gitAge {
+		processOrphanAges()
+	}
+
 	displayOrphans()
 
+	// Write the component documentation
+	if len(docsDir) > 0 {
+		if err = generateDocs(); err != nil {
+			fmt.Fprintln(logWriter, err)
+			return 2
+		}
+	}
+
+	// Build the patch to remove the orphans
+	if removeOrphans {
+		if err = generateRemoval(); err != nil {
+			fmt.Fprintln(logWriter, err)
+			return 2
+		}
+	}
+
+	// List the invokes that were commented out
+	if listCommented {
+		displayCommented()
+	}
+
+	// Find the cycles of calls between components
+	if cycleReport {
+		processCycles()
+	}
+
 	// Display cross references
 	if crossRefAll {
 		// Process each name from the xref's
@@ -158,43 +296,110 @@ func main() {
 
 	fmt.Fprintf(logWriter, "\n")
 	fmt.Fprintf(logWriter, "There are %d components defined with a total of %d functions\n", len(xref), totalFuncs)
-	fmt.Fprintf(logWriter, "There are %d cfinvoke calls processed\n", len(deferredList))
+	fmt.Fprintf(logWriter, "There are %d cfinvoke and script calls processed\n", len(deferredList))
+	fmt.Fprintf(logWriter, "There are %d calls only known at run time\n", len(dynamicList))
+	fmt.Fprintf(logWriter, "There are %d cfinvoke calls commented out\n", len(commentedList))
 	fmt.Fprintf(logWriter, "Number of missing referenced functions: %d\n", missingFuncCt)
 	fmt.Fprintf(logWriter, "Number of missing referenced methods: %d\n", missingMethodCt)
+	fmt.Fprintf(logWriter, "Number of references to components in skipped directories: %d\n", skippedRefCt)
+	fmt.Fprintf(logWriter, "Number of references to excluded components: %d\n", excludedRefCt)
+	fmt.Fprintf(logWriter, "Number of functions called by framework conventions: %d\n", providerCallCt)
 	fmt.Fprintf(logWriter, "Number of orphaned components: %d\n", orphanCompCt)
 	fmt.Fprintf(logWriter, "Number of orphaned functions: %d\n", orphanFuncCt)
+	fmt.Fprintf(logWriter, "Number of possible SQL injection points: %d\n", sqlLintCt)
+	fmt.Fprintf(logWriter, "Number of files whose encoding was guessed: %d\n", guessedEncodingCt)
+	fmt.Fprintf(logWriter, "Number of cycles between components: %d, new: %d\n", cycleCt, len(newCycles))
+
+	if cycleFailNew && len(newCycles) > 0 {
+		fmt.Fprintf(logWriter, "Processing failed, there are new cycles between components\n")
+		fmt.Fprintf(os.Stderr, "Processing failed, there are %d new cycles between components\n", len(newCycles))
+		return cycleFailStatus
+	}
+
 	fmt.Fprintln(logWriter, "Processing completed successfully")
 
 	timeFinish := time.Now().Unix()
 	fmt.Fprintf(logWriter, "Build time: %d seconds, Analysis and reporting: %d seconds, total: %d\n", timeBuild-timeStart, timeFinish-timeBuild, timeFinish-timeStart)
 	fmt.Fprintf(os.Stdout, "Build time: %d seconds, Analysis and reporting: %d seconds, total: %d\n", timeBuild-timeStart, timeFinish-timeBuild, timeFinish-timeStart)
 	fmt.Fprintln(os.Stdout, "Processing completed successfully")
+
+	// Keep the reports live while files are edited
+	if watchInterval > 0 {
+		watchTree()
+	}
+
+	return 0
 }
 
 // pgmUsage Display sample usage
 func pgmUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: cfxref config.json {optional list of components to show cross reference or all}\n\n")
+	fmt.Fprintf(os.Stderr, "Usage: cfxref config.json {optional list of components to show cross reference or all}\n")
+	fmt.Fprintf(os.Stderr, "       cfxref config.json watch {optional seconds between checks, default 5}\n")
+	fmt.Fprintf(os.Stderr, "       cfxref config.json rename component.oldMethod newMethod {patch file (.diff or .patch) or output directory}\n")
+	fmt.Fprintf(os.Stderr, "       cfxref config.json impact {changed files, component.method names or @file listing them, which may be dumpcrc output}\n\n")
 	fmt.Fprintf(os.Stderr, "Sample JSON:\n%s\n",
 		`
 {
     "verbose"   : true,
+    "commented" : true,
     "webroot"   : "c:/Development/Rotary_CURRENT",
     "paths"     : ["/CFC", "/CFC2"],
     "vars"      : {"APPLICATION.DIR":"/CFC", "APPLICATION.SITECFCDIRECTORY": "/CFC"},
     "exclude"   : ["/Application.cfc"],
     "skipdirs"  : ["Dir/OldFiles", "Dir2/OldFiles"],
-    "save"      : {"missing":"missing.txt", "orphans":"orphans.txt", "log":"log.txt"}
+    "encodings" : {"/Legacy":"windows-1252", "/Legacy/Unicode":"utf-16le"},
+    "indexskipped" : false,
+    "workspace" : {"apps":[{"name":"members", "webroot":"c:/Sites/Members", "paths":["/CFC"], "vars":{},
+                            "mappings":{"/common":"c:/Shared/Common"}}],
+                   "libraries":[{"name":"common", "webroot":"c:/Shared/Common"}]},
+    "sqlallow"  : ["i", "arguments\\.\\w+ID"],
+    "removeorphans" : {"patch":"orphans.diff", "orphans":"confirmed.txt", "dryrun":false},
+    "docs"      : "c:/Development/docs",
+    "git"       : {"range":"origin/main..HEAD", "age":true},
+    "cycles"    : {"baseline":"cycles.txt", "failnew":true},
+    "frameworks": {"coldbox":true, "fw1":["controllers"],
+                   "patterns":[{"name":"Taffy", "component":"^/resources/", "method":"^(get|post|put|delete)$"}]},
+    "save"      : {"missing":"missing.txt", "orphans":"orphans.txt", "log":"log.txt", "sql":"sql.txt",
+                   "commented":"commented.txt", "cycles":"cycles.txt"}
 }`)
 
 	fmt.Fprintf(os.Stderr, "Keywords\n")
 	fmt.Fprintf(os.Stderr, "%s: a flag to enable verbose logging (boolean: true|false)\n", KwVerbose)
+	fmt.Fprintf(os.Stderr, "%s: a flag to list cfinvoke calls inside comments (boolean: true|false)\n", KwComment)
 	fmt.Fprintf(os.Stderr, "%s: The fully qualified web root directory\n", KwRoot)
 	fmt.Fprintf(os.Stderr, "%s: An array of path names relative to the web root\n", KwPaths)
 	fmt.Fprintf(os.Stderr, "%s: A set of json varname=value specifications\n", KwVars)
+	fmt.Fprintf(os.Stderr, "%s: Several applications and shared libraries in place of %s, each with a %s, %s, %s, %s and %s\n",
+		KwWorkspace, KwRoot, KwName, KwRoot, KwPaths, KwVars, KwMappings)
+	fmt.Fprintf(os.Stderr, "    The top level %s and %s apply to all of them, the names are shown as app:/path (app/path in patches)\n", KwPaths, KwVars)
 	fmt.Fprintf(os.Stderr, "%s: An array of cfc names relative to the root (i.e. /Application.cfc\n", KwExcludes)
 	fmt.Fprintf(os.Stderr, "%s: An array of directory names relative to the root (i.e. /Application.cfc\n", KwSkipDirs)
-	fmt.Fprintf(os.Stderr, "%s: A set of JSON variables for outputtingdata\nVariables are 'missing' and 'orphans' (default is display)\n", KwSave)
+	fmt.Fprintf(os.Stderr, "%s: The encoding of the files in a directory relative to the root, used when a file has no byte order mark\n", KwEncodings)
+	fmt.Fprintf(os.Stderr, "    Encodings are utf-8, utf-16le, utf-16be, windows-1252 and iso-8859-1\n")
+	fmt.Fprintf(os.Stderr, "%s: Index the components in the skipped directories so calls to them resolve, without reporting their orphans (boolean: true|false)\n", KwIndexSkp)
+	fmt.Fprintf(os.Stderr, "%s: An array of regular expressions for #variables# that are safe to use unparameterized in a cfquery\n", KwSQLAllow)
+	fmt.Fprintf(os.Stderr, "%s: A directory to write HTML and Markdown documentation for each component\n", KwDocs)
+	fmt.Fprintf(os.Stderr, "%s: Compare the cycles of calls between components with a previous run\n", KwCycles)
+	fmt.Fprintf(os.Stderr, "    baseline: a previous cycle report, cycles not in it are reported as new\n")
+	fmt.Fprintf(os.Stderr, "    failnew: exit with status %d when there are new cycles (boolean: true|false)\n", cycleFailStatus)
+	fmt.Fprintf(os.Stderr, "%s: Frameworks calling methods by convention, which are not orphans\n", KwFrameworks)
+	fmt.Fprintf(os.Stderr, "    coldbox: the public methods of the components in handlers directories (true|false or an array of directory names)\n")
+	fmt.Fprintf(os.Stderr, "    fw1: the public methods of the components in controllers directories (true|false or an array of directory names)\n")
+	fmt.Fprintf(os.Stderr, "    patterns: an array of a name with regular expressions for the component file names and the method names called\n")
+	fmt.Fprintf(os.Stderr, "    A pattern may also name the application of the workspace holding its components\n")
+	fmt.Fprintf(os.Stderr, "%s: Use the git repository holding the web root\n", KwGit)
+	fmt.Fprintf(os.Stderr, "    range: only report missing and orphaned functions in files changed in the revision range\n")
+	fmt.Fprintf(os.Stderr, "    age: annotate each orphan with the date and author of the last commit touching it (boolean: true|false)\n")
+	fmt.Fprintf(os.Stderr, "%s: Write a unified diff removing orphaned functions and their comments\n", KwRemove)
+	fmt.Fprintf(os.Stderr, "    patch: name of the patch file, relative to the web root (apply with patch -p1)\n")
+	fmt.Fprintf(os.Stderr, "    orphans: optional orphan list in the orphan output format, limiting what is removed\n")
+	fmt.Fprintf(os.Stderr, "    dryrun: only show the lines removed per file (boolean: true|false)\n")
+	fmt.Fprintf(os.Stderr, "%s: A set of JSON variables for outputtingdata\nVariables are 'missing', 'orphans', 'log', 'sql', 'commented' and 'cycles' (default is display)\n", KwSave)
 	fmt.Fprintf(os.Stderr, "NOTE: By Default the directory .svn is always skipped\n")
+	fmt.Fprintf(os.Stderr, "NOTE: Tags inside CFML comments and cfscript comments are ignored\n")
+	fmt.Fprintf(os.Stderr, "NOTE: JavaScript and HTML files are scanned for URL, AJAX and cfajaxproxy calls to components\n")
+	fmt.Fprintf(os.Stderr, "NOTE: Files without a byte order mark that are not UTF-8 are read as Windows-1252 (or UTF-16 when it looks like it) and reported\n")
+	fmt.Fprintf(os.Stderr, "NOTE: Components with accessors=\"true\" have implicit getters and setters for their properties\n")
 }
 
 // getParms Get the parms from the command line argument and process
@@ -226,13 +431,24 @@ func getParms() error {
 	var missingFileName string
 	var orphanFileName string
 	var logFileName string
+	var sqlFileName string
+	var commentedFileName string
+	var cycleFileName string
 
 	for key, val := range argMap {
 		switch strings.ToLower(key) {
 		case KwVerbose:
 			verboseMode = val.(bool)
+		case KwComment:
+			listCommented = val.(bool)
 		case KwRoot:
 			rootDir = val.(string)
+		case KwWorkspace:
+			passed = parseWorkspace(val) && passed
+		case KwFrameworks:
+			passed = parseProviders(val) && passed
+		case KwEncodings:
+			passed = parseEncodings(val) && passed
 		case KwPaths:
 			values := val.([]interface{})
 			for _, path := range values {
@@ -258,6 +474,72 @@ func getParms() error {
 				dirStr := cleanDirName(dir.(string))
 				skipDirs[strings.ToLower(dirStr)] = nil
 			}
+		case KwSQLAllow:
+			values := val.([]interface{})
+			for _, pattern := range values {
+				// Anchor the pattern so it has to match the whole expression
+				regex, err := regexp.Compile(`^(?i:` + strings.TrimSpace(pattern.(string)) + `)$`)
+
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "The %s pattern '%s' is invalid: %s\n", KwSQLAllow, pattern, err)
+					passed = false
+					continue
+				}
+
+				sqlAllow = append(sqlAllow, regex)
+			}
+		case KwDocs:
+			docsDir = val.(string)
+		case KwCycles:
+			cycleReport = true
+			specs := val.(map[string]interface{})
+			for option, setting := range specs {
+				switch option {
+				case "baseline":
+					cycleBaselineName = setting.(string)
+				case "failnew":
+					cycleFailNew = setting.(bool)
+				default:
+					fmt.Fprintf(os.Stderr, "Invalid %s parameter '%s'\n", KwCycles, option)
+					passed = false
+				}
+			}
+		case KwIndexSkp:
+			indexSkipped = val.(bool)
+		case KwGit:
+			specs := val.(map[string]interface{})
+			for option, setting := range specs {
+				switch option {
+				case "range":
+					gitRange = setting.(string)
+				case "age":
+					gitAge = setting.(bool)
+				default:
+					fmt.Fprintf(os.Stderr, "Invalid %s parameter '%s'\n", KwGit, option)
+					passed = false
+				}
+			}
+		case KwRemove:
+			removeOrphans = true
+			specs := val.(map[string]interface{})
+			for option, setting := range specs {
+				switch option {
+				case "patch":
+					removePatchName = setting.(string)
+				case "orphans":
+					removeListName = setting.(string)
+				case "dryrun":
+					removeDryRun = setting.(bool)
+				default:
+					fmt.Fprintf(os.Stderr, "Invalid %s parameter '%s'\n", KwRemove, option)
+					passed = false
+				}
+			}
+
+			if len(removePatchName) == 0 && !removeDryRun {
+				fmt.Fprintf(os.Stderr, "A patch file name is required for %s unless it is a dry run\n", KwRemove)
+				passed = false
+			}
 		case KwSave:
 			specs := val.(map[string]interface{})
 			for option, filename := range specs {
@@ -268,6 +550,13 @@ func getParms() error {
 					orphanFileName = filename.(string)
 				case "log":
 					logFileName = filename.(string)
+				case "sql":
+					sqlFileName = filename.(string)
+				case "commented":
+					commentedFileName = filename.(string)
+				case "cycles":
+					cycleReport = true
+					cycleFileName = filename.(string)
 				default:
 					fmt.Fprintf(os.Stderr, "Invalid %s parameter '%s'\n", KwSave, option)
 					passed = false
@@ -279,7 +568,37 @@ func getParms() error {
 	}
 
 	// Get optional parameters
-	if len(os.Args) > 2 {
+	if len(os.Args) > 2 && strings.EqualFold(os.Args[2], "rename") {
+		// Rename a method: rename component.oldMethod newMethod output
+		if len(os.Args) != 6 {
+			fmt.Fprintf(os.Stderr, "A rename requires the component.method, the new method name and the output name\n")
+			passed = false
+		} else {
+			renameTarget = os.Args[3]
+			renameTo = os.Args[4]
+			renameOutput = os.Args[5]
+		}
+	} else if len(os.Args) > 2 && strings.EqualFold(os.Args[2], "impact") {
+		// Find the callers of changed files and functions: impact names...
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "An impact analysis requires the changed files or component.method names\n")
+			passed = false
+		} else {
+			impactNames = os.Args[3:]
+		}
+	} else if len(os.Args) > 2 && strings.EqualFold(os.Args[2], "watch") {
+		// Keep the reports up to date: watch {seconds}
+		watchInterval = 5
+
+		if len(os.Args) > 3 {
+			watchInterval, err = strconv.Atoi(os.Args[3])
+
+			if err != nil || watchInterval <= 0 {
+				fmt.Fprintf(os.Stderr, "The watch interval '%s' must be a positive number of seconds\n", os.Args[3])
+				passed = false
+			}
+		}
+	} else if len(os.Args) > 2 {
 		for index := 2; index < len(os.Args); index++ {
 			if strings.EqualFold(os.Args[index], "all") {
 				if crossRefAll {
@@ -322,13 +641,48 @@ func getParms() error {
 		}
 	}
 
-	// Root dir is required
-	if len(rootDir) == 0 {
+	if len(sqlFileName) > 0 {
+		sqlWriter, err = os.Create(sqlFileName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(commentedFileName) > 0 {
+		commentedWriter, err = os.Create(commentedFileName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// The baseline may be the previous cycle output, so read it before the output is created
+	if len(cycleBaselineName) > 0 {
+		if err = loadCycleBaseline(); err != nil {
+			return err
+		}
+	}
+
+	if len(cycleFileName) > 0 {
+		cycleWriter, err = os.Create(cycleFileName)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// Root dir is required unless a workspace gives the roots
+	if len(rootDir) == 0 && !workspaceMode {
 		fmt.Fprintf(os.Stderr, "The root directory specification is required\n")
 		passed = false
+	} else if len(rootDir) > 0 && workspaceMode {
+		fmt.Fprintf(os.Stderr, "Use either %s or %s, not both\n", KwRoot, KwWorkspace)
+		passed = false
 	} else {
 		// Get the size of the root dir spec
 		rootDirSize = len(rootDir)
+		setupRoots()
 	}
 
 	// Add in subversion as a directory to skip
@@ -344,9 +698,28 @@ func getParms() error {
 }
 
 func walkTree(path string, info os.FileInfo, callerErr error) error {
+	// Only process the files that are wanted
+	if process, result := selectPath(path, info, callerErr); !process {
+		return result
+	}
+
+	// Process the file
+	result := parseFile(path)
+
+	if result != nil {
+		fmt.Println(result)
+	}
+
+	// Return the result
+	return result
+}
+
+// selectPath Check if a path found walking the tree is a file to process
+// returns whether to process the file and the result for the walk (i.e. to skip a directory)
+func selectPath(path string, info os.FileInfo, callerErr error) (bool, error) {
 	// If you can't access the info, skip the file
 	if info == nil || callerErr != nil {
-		return nil
+		return false, nil
 	}
 
 	// Only process valid cfm or cfc file names and not directories
@@ -362,8 +735,13 @@ func walkTree(path string, info os.FileInfo, callerErr error) error {
 			_, exists := skipDirs[strings.ToLower(relPath)]
 
 			if exists {
+				// Remember the directory to tell skipped components from missing ones
+				if !strings.EqualFold(relPath, "/.svn") {
+					skippedDirs[path] = nil
+				}
+
 				// Skip the entire directory
-				return filepath.SkipDir
+				return false, filepath.SkipDir
 			}
 
 		}
@@ -374,23 +752,16 @@ func walkTree(path string, info os.FileInfo, callerErr error) error {
 		}
 
 		// DOn't process an actual directory
-		return nil
+		return false, nil
 	} else {
 		// Processing a file but make sure it's one we want
-		if !regexpFName.MatchString(filepath.Base(path)) {
-			return nil
+		if !regexpFName.MatchString(filepath.Base(path)) && !isBrowserFile(path) {
+			return false, nil
 		}
 	}
 
 	// Process the file
-	result := parseFile(path)
-
-	if result != nil {
-		fmt.Println(result)
-	}
-
-	// Return the result
-	return result
+	return true, nil
 }
 
 // cleanDirName Clean up a directory name to a standard format for specifying a component name
@@ -425,21 +796,32 @@ func parseFile(fileName string) (err error) {
 	// Skip this file if requested
 	for _, skip := range excludes {
 		if strings.HasSuffix(strings.ToLower(strings.ReplaceAll(fileName, `\`, "/")), skip) {
+			unindexed[componentKey(fileName)] = reasonExcluded
 			return nil
 		}
 	}
 
-	// Open and defer the closing of the file
-	file, err := os.Open(fileName)
+	// JavaScript and HTML are only scanned for calls to remote methods
+	if isBrowserFile(fileName) {
+		return parseBrowserFile(fileName)
+	}
+
+	// Read the file as UTF-8 whatever its encoding
+	content, err := readSource(fileName)
 	if err != nil {
 		return err
 	}
 
-	defer file.Close()
-
 	// Read each line and process
 	lineNo := 0
-	scanner := bufio.NewScanner(file)
+	lint := sqlState{}
+	remote := remoteState{}
+	browserScript := false
+	docs := docState{}
+	scope := funcScope{}
+	comments := newCommentState(fileName)
+	comments.detectScript(content)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
 	scanner.Buffer(scanBuff, 5000000)
 
 	for scanner.Scan() {
@@ -462,28 +844,93 @@ func parseFile(fileName string) (err error) {
 				2.4 and its valu4
 		*/
 		currentLine = scanner.Text()
-		matches := regExp.FindAllStringSubmatch(currentLine, -1)
 
-		if len(matches) == 0 {
-			continue
+		// Only look at the code, but remember invokes that were commented out
+		code, comment := comments.strip(currentLine)
+
+		text, startLine := comments.commented(comment, lineNo)
+		docs.recordComment(text)
+
+		if len(text) > 0 && !definitionsOnly {
+			scanCommented(text, fileName, startLine)
 		}
 
+		matches := regExp.FindAllStringSubmatch(code, -1)
+
 		// Process cffunction or cfinvoke
 		//fmt.Printf("Processing %s at line %d\n", fileName, lineNo)
-		switch strings.ToLower(matches[0][1]) {
-		case "cffunction":
-			// Process the cffunction
-			if err = processFunction(matches, fileName, lineNo); err != nil {
-				return err
+		if len(matches) > 0 {
+			switch strings.ToLower(matches[0][1]) {
+			case "cffunction":
+				// Process the cffunction
+				if scope, err = processFunction(matches, fileName, lineNo); err != nil {
+					return err
+				}
+
+			case "cfinvoke":
+				// Save the invokes and process after all the functions have been built
+				if definitionsOnly {
+					break
+				}
+
+				if err = deferInvoke(matches, fileName, lineNo, code); err != nil {
+					return err
+				}
+
+			default:
 			}
+		}
+
+		// Method calls may be made from script or from expressions in tags
+		if !definitionsOnly {
+			// The JavaScript of a page only calls components through cfajaxproxy objects
+			cfml, javaScript := splitBrowserScript(&browserScript, code)
+			scanScriptCalls(fileName, lineNo, cfml)
+			scanProxyCalls(fileName, lineNo, javaScript)
+			scanRemoteCalls(&remote, fileName, lineNo, code)
+		}
 
-		case "cfinvoke":
-			// Save the invokes and process after all the functions have been built
-			if err = deferInvoke(matches, fileName, lineNo); err != nil {
-				return err
+		// Script functions are found by the function keyword, with the braces counted from there
+		braceText := code
+
+		if comments.scriptMode() {
+			if found := regexpScriptFunc.FindStringSubmatchIndex(code); found != nil && scope.closedBy(code[:found[0]]) {
+				// The previous function may end on the line the next one starts on
+				if len(scope.name) > 0 {
+					scope.end(lineNo)
+				}
+
+				scope = defineFunction(fileName, code[found[2]:found[3]], lineNo, true)
+				braceText = code[found[1]:]
+				docs.recordScriptFunction(scope, code[scriptFuncStart(code, found):])
 			}
+		}
 
-		default:
+		// Keep the hints and other attributes for the documentation
+		recordDocs(&docs, scope, fileName, code, comments.scriptMode())
+		recordProperty(scope, fileName, lineNo, code, comments.scriptMode())
+
+		// Keep track of the enclosing function for the SQL lint
+		lint.funcName = scope.name
+
+		if !definitionsOnly {
+			lintSQL(&lint, fileName, lineNo, code)
+		}
+
+		// Check for the end of the current function
+		if len(scope.name) > 0 {
+			if scope.script {
+				scope.depth += countBraces(braceText)
+				scope.opened = scope.opened || strings.Contains(braceText, "{")
+
+				if scope.opened && scope.depth <= 0 {
+					scope.end(lineNo)
+					scope = funcScope{}
+				}
+			} else if strings.Contains(strings.ToLower(code), "</cffunction") {
+				scope.end(lineNo)
+				scope = funcScope{}
+			}
 		}
 	}
 
@@ -500,49 +947,140 @@ func parseFile(fileName string) (err error) {
 // fileName: File name being processed for diagnostics
 // lineNo: line number in that file the function definition occurred
 // compFuncs: Component function information for that file
-func processFunction(matches [][]string, fileName string, lineNo int) (err error) {
+// returns the scope of the function for finding where it ends
+func processFunction(matches [][]string, fileName string, lineNo int) (scope funcScope, err error) {
+	if len(matches) == 1 {
+		fmt.Fprintf(logWriter, "Error for file %s at line %d\n", fileName, lineNo)
+		fmt.Fprintf(logWriter, "Text:'%s'\n", currentLine)
+		fmt.Println(currentLine)
+	}
+
+	// Make sure the 'name' keyword is defined
+	if !strings.EqualFold("name", matches[1][3]) {
+		return scope, fmt.Errorf("the cffunction at line %d in file %s is invalid", lineNo, fileName)
+	}
+
+	// Process the name of this function
+	scope = defineFunction(fileName, matches[1][4], lineNo, false)
+
+	// Return result
+	return scope, err
+}
+
+// defineFunction Add a function definition to the component for the file
+// fileName: File name the function is defined in
+// funcName: Name of the function
+// lineNo: line number in that file the function definition starts on
+// script: The function is written in cfscript
+func defineFunction(fileName string, funcName string, lineNo int, script bool) funcScope {
 	// Get the component name from the file name and create a key name
-	compName := removeSuffix(strings.ReplaceAll(fileName[rootDirSize:], "\\", "/"))
+	compName := removeSuffix(relativeName(fileName))
 	mapName := strings.ToLower(compName)
 
 	// Get the function definition for this file, creating one if not found
 	componentDefinition, valid := xref[mapName]
 
 	if !valid {
-		componentDefinition = compDef{name: compName, funcs: make(map[string]funcDef)}
+		componentDefinition = compDef{name: compName, fileName: fileName, funcs: make(map[string]funcDef)}
 		xref[mapName] = componentDefinition
 	}
 
-	functionDefinitions := componentDefinition.funcs
+	// Setup the function definition for this function
+	funcKey := strings.ToLower(funcName)
+	componentDefinition.funcs[funcKey] = funcDef{name: funcName, line: lineNo, endLine: lineNo, script: script,
+		usedBy: make(map[string]funcUsage)}
 
-	if len(matches) == 1 {
-		fmt.Fprintf(logWriter, "Error for file %s at line %d\n", fileName, lineNo)
-		fmt.Fprintf(logWriter, "Text:'%s'\n", currentLine)
-		fmt.Println(currentLine)
+	// Increment the number of functions
+	totalFuncs++
+
+	return funcScope{mapName: mapName, funcKey: funcKey, name: funcName, script: script}
+}
+
+// end Record the line the function definition ends on
+func (scope *funcScope) end(lineNo int) {
+	functions := xref[scope.mapName].funcs
+
+	if definition, found := functions[scope.funcKey]; found {
+		definition.endLine = lineNo
+		functions[scope.funcKey] = definition
 	}
+}
 
-	// Make sure the 'name' keyword is defined
-	if !strings.EqualFold("name", matches[1][3]) {
-		return fmt.Errorf("the cffunction at line %d in file %s is invalid", lineNo, fileName)
+// closedBy Check if the current function is ended by the text before the next function on the same line
+// text: The code in front of the next function definition
+func (scope *funcScope) closedBy(text string) bool {
+	if len(scope.name) == 0 {
+		return true
 	}
 
-	// Process the name of this function
-	funcName := matches[1][4]
+	return scope.script && (scope.opened || strings.Contains(text, "{")) && scope.depth+countBraces(text) <= 0
+}
 
-	// Setup the function definition for this function
-	functionDefinitions[strings.ToLower(funcName)] = funcDef{name: funcName, usedBy: make(map[string]funcUsage)}
+// scriptFuncStart Get the position of the access or return type (or the function keyword) starting a script
+// function definition, past any separator matched in front of it
+// found: Location of the match of regexpScriptFunc
+func scriptFuncStart(code string, found []int) int {
+	start := found[0]
 
-	// Increment the number of functions
-	totalFuncs++
+	for start < found[2] && strings.IndexByte(" \t;{}>", code[start]) >= 0 {
+		start++
+	}
 
-	// Return result
-	return err
+	return start
+}
+
+// countBraces Get the change in brace depth for a line of script, ignoring braces in strings
+func countBraces(code string) int {
+	depth := 0
+	quote := byte(0)
+
+	for index := 0; index < len(code); index++ {
+		switch {
+		case quote != 0:
+			if code[index] == quote {
+				quote = 0
+			}
+		case code[index] == '"' || code[index] == '\'':
+			quote = code[index]
+		case code[index] == '{':
+			depth++
+		case code[index] == '}':
+			depth--
+		}
+	}
+
+	return depth
 }
 
-func deferInvoke(matches [][]string, fileName string, lineNo int) (err error) {
-	// Get and process the component and method parameters
+// deferInvoke Save the cfinvoke information for processing after all the functions have been found
+// code: Text of the line, used to locate the method name
+func deferInvoke(matches [][]string, fileName string, lineNo int, code string) (err error) {
+	funcCall, valid := parseInvoke(matches, fileName, lineNo)
+
+	// The method attribute of the cfinvoke tag, not of another tag on the line
+	if start := indexTag(strings.ToLower(code), "<cfinvoke"); start >= 0 {
+		if location := regexpMethodAttr.FindStringSubmatchIndex(tagText(code, start)); location != nil {
+			funcCall.column = start + location[2]
+		}
+	}
+
+	// Keep track of the invokes that can't be determined until run time
+	if !valid || strings.Contains(funcCall.method, "#") {
+		funcCall.dynamic = true
+		dynamicList = append(dynamicList, funcCall)
+	}
+
+	if valid {
+		deferredList = append(deferredList, funcCall)
+	}
+
+	return err
+}
 
-	fileName = strings.ReplaceAll(fileName[rootDirSize:], `\`, "/")
+// parseInvoke Get and process the component and method parameters of a cfinvoke
+// returns the invoke information and whether it could be resolved
+func parseInvoke(matches [][]string, fileName string, lineNo int) (defInvoke, bool) {
+	fileName = relativeName(fileName)
 	funcCall := defInvoke{fileName: fileName, line: lineNo}
 
 	// Process keywords
@@ -574,6 +1112,8 @@ func deferInvoke(matches [][]string, fileName string, lineNo int) (err error) {
 		component = removeSuffix(fileName)
 	}
 
+	funcCall.method = method
+
 	// Expand any variable specifications
 	varInstances := findVars.FindAllString(component, -1)
 
@@ -582,18 +1122,17 @@ func deferInvoke(matches [][]string, fileName string, lineNo int) (err error) {
 
 		if !found {
 			fmt.Fprintf(logWriter, "The variable '%s' was not found", spec)
-			return nil
+			funcCall.component = component
+			return funcCall, false
 		}
 
 		component = strings.ReplaceAll(component, spec, replacement)
 	}
 
 	// Save the info for this invocation
-	funcCall.component = component
-	funcCall.method = method
+	funcCall.component = normalizeComponent(component)
 
-	deferredList = append(deferredList, funcCall)
-	return err
+	return funcCall, true
 }
 
 // removeSuffix Remove file suffix
@@ -611,22 +1150,42 @@ func removeSuffix(name string) string {
 func processInvoke() {
 	// Iterate through each invoke
 	for _, spec := range deferredList {
-		compInfo, err := lookupComponent(spec.component)
+		compInfo, err := lookupComponent(spec.component, spec.fileName)
+		reported := inChangeSet(spec.fileName)
+
+		// Calls found in script only locate the call sites for a rename
+		if spec.renameOnly() {
+			continue
+		}
+
+		if err != nil && !reported {
+			continue
+		}
+
+		if err != nil && reportUnindexed(spec) {
+			continue
+		}
 
 		if err != nil {
 			missingFuncCt++
-			fmt.Fprintf(missingWriter, "The component %s referenced in %s at line %d was not found\n",
-				spec.component, spec.fileName, spec.line)
+			missingRefs[spec.fileName+" "+spec.component] = nil
+			fmt.Fprintf(missingWriter, "The component %s referenced in %s at line %d was not found%s\n",
+				spec.component, spec.fileName, spec.line, suggestComponent(spec.component, spec.fileName))
 			continue
 		}
 
 		// Lookup the method in the function
 		funcInfo, found := compInfo[strings.ToLower(spec.method)]
 
+		if !found && !reported {
+			continue
+		}
+
 		if !found {
 			missingMethodCt++
-			fmt.Fprintf(missingWriter, "The method    %s referenced in %s at line %d was not found in function %s\n",
-				spec.method, spec.fileName, spec.line, spec.component)
+			missingRefs[spec.fileName+" "+spec.component+"."+spec.method] = nil
+			fmt.Fprintf(missingWriter, "The method    %s referenced in %s at line %d was not found in function %s%s\n",
+				spec.method, spec.fileName, spec.line, spec.component, suggestMethod(compInfo, spec.method))
 			continue
 		}
 
@@ -646,38 +1205,44 @@ func processInvoke() {
 }
 
 // lookupComponent Normalizes a component name and finds it in the xref
-func lookupComponent(compName string) (map[string]funcDef, error) {
-	// Convert to lower case firt
-	key := strings.ToLower(compName)
+// caller: Relative name of the file making the call
+func lookupComponent(compName string, caller string) (map[string]funcDef, error) {
+	key, err := resolveComponent(compName, caller)
 
-	// Check for the native name first
-	result, found := xref[key]
-
-	if found {
-		return result.funcs, nil
+	if err != nil {
+		return nil, err
 	}
 
-	// Not there so try the tag paths
-	for _, path := range tagPath {
-		pathKey := path + "/" + key
-		result, found = xref[pathKey]
+	return xref[key].funcs, nil
+}
 
-		if found {
-			return result.funcs, nil
+// resolveComponent Normalizes a component name and finds its key in the xref
+// caller: Relative name of the file making the call, which selects the application in a workspace
+func resolveComponent(compName string, caller string) (string, error) {
+	// Check for the native name first, then the application, the mappings and the tag paths
+	for _, key := range componentCandidates(compName, caller) {
+		if _, found := xref[key]; found {
+			return key, nil
 		}
 	}
 
 	// No joy
-	return nil, fmt.Errorf("the component '%s' was not found in the default path or in %q", compName, tagPath)
+	return "", fmt.Errorf("the component '%s' was not found in the default path or in %q", compName, tagPath)
 }
 
 // processOrphans Funused components and functions
 func processOrphans() {
 	// Process each component in the crossref
 	for _, component := range xref {
+		// Only report on the files changed when a revision range is given, and not on skipped directories
+		if component.definitionOnly || !inChangeSet(relativeName(component.fileName)) {
+			continue
+		}
+
 		// Process each function for this component
 		for _, functions := range component.funcs {
-			if len(functions.usedBy) == 0 {
+			// Implicit accessors are not reported since there is no code to remove
+			if len(functions.usedBy) == 0 && !functions.implicit && len(functions.provider) == 0 {
 				orphanList, found := orphans[component.name]
 
 				// Allocate a new list
@@ -699,6 +1264,12 @@ func processOrphans() {
 
 // Display orphan information
 func displayOrphans() {
+	// List the oldest orphans first when the ages are known
+	if gitAge {
+		displayOrphanAges()
+		return
+	}
+
 	fmt.Fprintf(orphanWriter, "Orphaned functions and their component\n")
 	for componentName, orphan := range orphans {
 		fmt.Fprintf(orphanWriter, "Component: %s\n", componentName)
@@ -724,7 +1295,14 @@ func crossReference(componentName string) {
 
 	// Iterate through all the functions
 	for _, functionMap := range componentDef.funcs {
-		fmt.Fprintf(logWriter, "    %s\n", functionMap.name)
+		if functionMap.implicit {
+			fmt.Fprintf(logWriter, "    %s (implicit)\n", functionMap.name)
+		} else if len(functionMap.provider) > 0 {
+			fmt.Fprintf(logWriter, "    %s (called by %s)\n", functionMap.name, functionMap.provider)
+		} else {
+			fmt.Fprintf(logWriter, "    %s\n", functionMap.name)
+		}
+
 		for _, usage := range functionMap.usedBy {
 			fmt.Fprintf(logWriter, "            %s %v\n", usage.cleanName, usage.useLines)
 		}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+)
+
+// useRoot Make a single web root the one being cross referenced for a test, restoring the roots after it
+func useRoot(t *testing.T, dir string) {
+	savedRoots, savedMode := roots, workspaceMode
+	t.Cleanup(func() {
+		roots, workspaceMode = savedRoots, savedMode
+	})
+
+	roots = []*rootDef{{dir: dir, variables: map[string]string{}, mappings: map[string]string{}}}
+	workspaceMode = false
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Comment tracking state for the file currently being scanned.  CFML comments may be nested and
+// both CFML and script block comments may span lines, so the state is carried from line to line
+type commentState struct {
+	cfmlDepth   int    // Nesting depth of <!--- ---> comments
+	inBlock     bool   // Inside a /* */ script comment
+	inScript    bool   // Inside a <cfscript> block
+	isCFC       bool   // The file is a component, which may be written entirely in script
+	scriptFile  bool   // The component is written in script
+	inTag       bool   // Inside a tag, where quoted attribute values may span lines
+	tagQuote    byte   // Quote of the attribute value being scanned in a tag
+	pending     string // Text of the comments not closed yet
+	pendingLine int    // Line the pending comment text started on
+}
+
+// Collection of cfinvoke calls found inside comments
+var commentedList = make([]defInvoke, 0, 1000)
+
+// newCommentState Setup the comment tracking for a file
+func newCommentState(fileName string) commentState {
+	return commentState{isCFC: strings.EqualFold(filepath.Ext(fileName), ".cfc")}
+}
+
+// detectScript Find whether a component is written in tags or in script from the text of the file
+func (c *commentState) detectScript(content []byte) {
+	c.scriptFile = c.isCFC && isScriptComponent(content)
+}
+
+// isScriptComponent Check if a component is written in script rather than tags, from its first text that
+// isn't a comment (i.e. component, import or an annotation rather than <cfcomponent>)
+func isScriptComponent(content []byte) bool {
+	text := string(content)
+
+	for index := 0; index < len(text); {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(text[index])):
+			index++
+
+		case strings.HasPrefix(text[index:], "<!---"):
+			// CFML comments may be nested
+			depth := 0
+
+			for index < len(text) {
+				if strings.HasPrefix(text[index:], "<!---") {
+					depth++
+					index += 5
+				} else if strings.HasPrefix(text[index:], "--->") {
+					depth--
+					index += 4
+
+					if depth == 0 {
+						break
+					}
+				} else {
+					index++
+				}
+			}
+
+		case strings.HasPrefix(text[index:], "//"):
+			if end := strings.IndexByte(text[index:], '\n'); end >= 0 {
+				index += end
+			} else {
+				index = len(text)
+			}
+
+		case strings.HasPrefix(text[index:], "/*"):
+			if end := strings.Index(text[index+2:], "*/"); end >= 0 {
+				index += end + 4
+			} else {
+				index = len(text)
+			}
+
+		default:
+			return text[index] != '<'
+		}
+	}
+
+	return false
+}
+
+// scriptMode Check if the scanner is currently processing script rather than tags
+func (c *commentState) scriptMode() bool {
+	return c.scriptFile || c.inScript
+}
+
+// strip Separate the code in a line from its comments
+// line: The text of the line
+// returns the line with all comment text blanked out (so columns are preserved) and the comment text
+func (c *commentState) strip(line string) (string, string) {
+	code := []byte(line)
+	lower := strings.ToLower(line)
+	comment := strings.Builder{}
+	quote := byte(0)
+
+	// Blank out a section of the code
+	blank := func(start int, size int) {
+		for index := start; index < start+size && index < len(code); index++ {
+			code[index] = ' '
+		}
+	}
+
+	for index := 0; index < len(line); {
+		switch {
+		case c.cfmlDepth > 0:
+			// Inside a CFML comment, which may be nested
+			if strings.HasPrefix(line[index:], "<!---") {
+				c.cfmlDepth++
+				blank(index, 5)
+				index += 5
+			} else if strings.HasPrefix(line[index:], "--->") {
+				c.cfmlDepth--
+				blank(index, 4)
+				index += 4
+
+				if c.cfmlDepth == 0 {
+					comment.WriteByte(' ')
+				}
+			} else {
+				comment.WriteByte(line[index])
+				blank(index, 1)
+				index++
+			}
+
+		case c.inBlock:
+			// Inside a script block comment
+			if strings.HasPrefix(line[index:], "*/") {
+				c.inBlock = false
+				blank(index, 2)
+				index += 2
+				comment.WriteByte(' ')
+			} else {
+				comment.WriteByte(line[index])
+				blank(index, 1)
+				index++
+			}
+
+		case quote != 0:
+			// Inside a script string, comment markers don't count
+			if line[index] == quote {
+				quote = 0
+			}
+
+			index++
+
+		case c.tagQuote != 0:
+			// Inside an attribute value of a tag, which may span lines
+			if line[index] == c.tagQuote {
+				c.tagQuote = 0
+			}
+
+			index++
+
+		case strings.HasPrefix(line[index:], "<!---"):
+			c.cfmlDepth = 1
+			blank(index, 5)
+			index += 5
+
+		default:
+			if c.scriptMode() {
+				if strings.HasPrefix(line[index:], "//") {
+					// Comment through the end of the line
+					comment.WriteString(line[index+2:])
+					blank(index, len(line)-index)
+					index = len(line)
+				} else if strings.HasPrefix(line[index:], "/*") {
+					c.inBlock = true
+					blank(index, 2)
+					index += 2
+				} else if line[index] == '"' || line[index] == '\'' {
+					quote = line[index]
+					index++
+				} else if !c.scriptFile && strings.HasPrefix(lower[index:], "</cfscript") {
+					c.inScript = false
+					index += len("</cfscript")
+				} else {
+					index++
+				}
+			} else if indexTag(lower[index:], "<cfscript") == 0 {
+				c.inScript = true
+				index += len("<cfscript")
+			} else {
+				// Comment markers in quoted attribute values don't count
+				switch {
+				case c.inTag && (line[index] == '"' || line[index] == '\''):
+					c.tagQuote = line[index]
+				case c.inTag && line[index] == '>':
+					c.inTag = false
+				case line[index] == '<' && index+1 < len(line) && isTagStart(line[index+1]):
+					c.inTag = true
+				}
+
+				index++
+			}
+		}
+	}
+
+	return string(code), comment.String()
+}
+
+// isTagStart Check if the character after a < starts a tag name or a closing tag
+func isTagStart(char byte) bool {
+	return char == '/' || (char|0x20 >= 'a' && char|0x20 <= 'z')
+}
+
+// commented Collect the comment text of each line until the comments are closed, so tags in a comment
+// may span lines
+// comment: Comment text of the line returned by strip
+// lineNo: Line number in the file
+// returns the text of the closed comments, with the lines separated by new lines, and the line they start on
+func (c *commentState) commented(comment string, lineNo int) (string, int) {
+	if len(c.pending) == 0 && len(comment) == 0 {
+		return "", 0
+	}
+
+	if len(c.pending) == 0 {
+		c.pendingLine = lineNo
+	} else {
+		c.pending += "\n"
+	}
+
+	c.pending += comment
+
+	// Wait for the end of a comment that is still open
+	if c.cfmlDepth > 0 || c.inBlock {
+		return "", 0
+	}
+
+	text := c.pending
+	c.pending = ""
+
+	return text, c.pendingLine
+}
+
+// scanCommented Save the cfinvoke calls found in the text of comments for listing
+// text: Comment text, with the lines separated by new lines
+// lineNo: Line the comment text starts on
+func scanCommented(text string, fileName string, lineNo int) {
+	matches := regExp.FindAllStringSubmatchIndex(text, -1)
+
+	for start := 0; start < len(matches); {
+		// Each tag runs through the attributes that follow it
+		end := start + 1
+
+		for end < len(matches) && matches[end][2] < 0 {
+			end++
+		}
+
+		if strings.EqualFold(subMatch(text, matches[start], 1), "cfinvoke") && end-start > 1 {
+			tag := make([][]string, 0, end-start)
+
+			for _, match := range matches[start:end] {
+				tag = append(tag, []string{text[match[0]:match[1]], subMatch(text, match, 1), subMatch(text, match, 2),
+					subMatch(text, match, 3), subMatch(text, match, 4)})
+			}
+
+			deferCommented(tag, fileName, lineNo+strings.Count(text[:matches[start][0]], "\n"))
+		}
+
+		start = end
+	}
+}
+
+// deferCommented Save a cfinvoke found inside a comment for listing
+func deferCommented(matches [][]string, fileName string, lineNo int) {
+	if funcCall, valid := parseInvoke(matches, fileName, lineNo); valid {
+		commentedList = append(commentedList, funcCall)
+	}
+}
+
+// displayCommented List the cfinvoke calls that have been commented out
+func displayCommented() {
+	fmt.Fprintf(commentedWriter, "Commented out cfinvoke calls\n")
+	for _, spec := range commentedList {
+		fmt.Fprintf(commentedWriter, "The method %s in component %s is invoked in a comment in %s at line %d\n",
+			spec.method, spec.component, spec.fileName, spec.line)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsScriptComponent(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(isScriptComponent([]byte("component {\n}")))
+	a.True(isScriptComponent([]byte("/**\n * Members\n */\ncomponent {\n}")))
+	a.True(isScriptComponent([]byte("// Members\nimport cfc.Base;\ncomponent extends=\"Base\" {\n}")))
+	a.True(isScriptComponent([]byte("<!--- <cfcomponent> --->\ncomponent {\n}")))
+	a.False(isScriptComponent([]byte("<cfcomponent>\n</cfcomponent>")))
+	a.False(isScriptComponent([]byte("<!--- Members <!--- nested ---> --->\n<cfcomponent>\n</cfcomponent>")))
+	a.False(isScriptComponent([]byte("")))
+}
+
+func TestStrip(t *testing.T) {
+	a := assert.New(t)
+	comments := newCommentState("/web/x.cfm")
+
+	code, comment := comments.strip(`<cfset x = 1> <!--- note ---> <cfset y = 2>`)
+	a.Equal(`<cfset x = 1>                 <cfset y = 2>`, code)
+	a.Equal(" note  ", comment)
+
+	// Comment markers in attribute values, which may span lines, are not comments
+	code, comment = comments.strip(`<cfset x = "<!--- not a comment`)
+	a.Equal(`<cfset x = "<!--- not a comment`, code)
+	a.Empty(comment)
+	code, _ = comments.strip(`--->"> <cfinvoke method="run">`)
+	a.Equal(`--->"> <cfinvoke method="run">`, code)
+	a.Zero(comments.cfmlDepth)
+
+	// Script comments only count in cfscript
+	code, comment = comments.strip(`<cfscript>x = "/* no */"; // yes`)
+	a.Equal(`<cfscript>x = "/* no */";       `, code)
+	a.Equal(" yes", comment)
+	a.True(comments.scriptMode())
+	comments.strip(`</cfscript>`)
+	a.False(comments.scriptMode())
+
+	script := newCommentState("/web/x.cfc")
+	script.detectScript([]byte("/* header */\ncomponent {\n}"))
+	a.True(script.scriptMode())
+}
+
+func TestCommented(t *testing.T) {
+	a := assert.New(t)
+	saved := commentedList
+	defer func() { commentedList = saved }()
+	commentedList = nil
+	useRoot(t, "/web")
+
+	lines := []string{
+		`<cfset x = 1>`,
+		`<!--- <cfinvoke component="cfc.Members"`,
+		`    method="getList"> --->`,
+		`<!--- <cfinvoke component="cfc.Members" method="save"> --->`,
+	}
+	comments := newCommentState("/web/x.cfm")
+
+	for index, line := range lines {
+		_, comment := comments.strip(line)
+
+		if text, startLine := comments.commented(comment, index+1); len(text) > 0 {
+			scanCommented(text, "/web/x.cfm", startLine)
+		}
+	}
+
+	if a.Len(commentedList, 2) {
+		a.Equal("getList", commentedList[0].method)
+		a.Equal(2, commentedList[0].line)
+		a.Equal("/cfc/Members", commentedList[0].component)
+		a.Equal("save", commentedList[1].method)
+		a.Equal(4, commentedList[1].line)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cycle report constants
+const (
+	cyclePrefix     = "Cycle: " // Start of the line listing the components in a cycle
+	cycleFailStatus = 1         // Exit status when new cycles fail the run
+)
+
+// Cycle settings
+var cycleReport bool = false             // Cycles are only found when their output or a baseline is configured
+var cycleWriter *os.File = os.Stderr     // Default component cycle output
+var cycleBaselineName string = ""        // Previous cycle report, the cycles in it are not new
+var cycleFailNew bool = false            // Fail the run when there are cycles not in the baseline
+var cycleBaseline map[string]interface{} // Signatures of the cycles in the baseline
+var cycleCt int = 0                      // Number of cycles found
+var newCycles = make([]string, 0)        // Cycles not in the baseline
+
+// A call from one component to another
+type callSite struct {
+	fileName string // Relative name of the calling file
+	line     int    // Line of the call
+	method   string // Method called
+}
+
+// loadCycleBaseline Get the cycles of a previous report
+func loadCycleBaseline() error {
+	cycleBaseline = make(map[string]interface{})
+	file, err := os.Open(cycleBaselineName)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, cyclePrefix) {
+			cycleBaseline[strings.ToLower(strings.TrimPrefix(line, cyclePrefix))] = nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// componentGraph Build the calls between components from the usage of their functions
+// returns the call sites by calling component key and called component key
+func componentGraph() map[string]map[string][]callSite {
+	graph := make(map[string]map[string][]callSite, len(xref))
+
+	for calledKey, component := range xref {
+		for _, function := range component.funcs {
+			for _, usage := range function.usedBy {
+				callerKey := strings.ToLower(removeSuffix(usage.cleanName))
+
+				// Only the calls made from other components count
+				if _, found := xref[callerKey]; !found || callerKey == calledKey || !strings.EqualFold(filepath.Ext(usage.cleanName), ".cfc") {
+					continue
+				}
+
+				if graph[callerKey] == nil {
+					graph[callerKey] = make(map[string][]callSite)
+				}
+
+				for _, line := range usage.useLines {
+					graph[callerKey][calledKey] = append(graph[callerKey][calledKey], callSite{fileName: usage.cleanName, line: line, method: function.name})
+				}
+			}
+		}
+	}
+
+	return graph
+}
+
+// stronglyConnected Find the strongly connected components of the graph with more than one member (Tarjan's algorithm)
+func stronglyConnected(graph map[string]map[string][]callSite) [][]string {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0)
+	result := make([][]string, 0)
+
+	var connect func(node string)
+	connect = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range calledKeys(graph[node]) {
+			if _, visited := index[next]; !visited {
+				connect(next)
+
+				if lowLink[next] < lowLink[node] {
+					lowLink[node] = lowLink[next]
+				}
+			} else if onStack[next] && index[next] < lowLink[node] {
+				lowLink[node] = index[next]
+			}
+		}
+
+		// The node is the root of a strongly connected component
+		if lowLink[node] == index[node] {
+			members := make([]string, 0)
+
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				members = append(members, member)
+
+				if member == node {
+					break
+				}
+			}
+
+			if len(members) > 1 {
+				sort.Strings(members)
+				result = append(result, members)
+			}
+		}
+	}
+
+	callers := make([]string, 0, len(graph))
+	for node := range graph {
+		callers = append(callers, node)
+	}
+
+	sort.Strings(callers)
+
+	for _, node := range callers {
+		if _, visited := index[node]; !visited {
+			connect(node)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i][0] < result[j][0] })
+
+	return result
+}
+
+// calledKeys Get the components called in order so the results are repeatable
+func calledKeys(called map[string][]callSite) []string {
+	keys := make([]string, 0, len(called))
+	for key := range called {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// shortestCycle Find the shortest path from the first member back to itself within the members
+func shortestCycle(graph map[string]map[string][]callSite, members []string) []string {
+	inside := make(map[string]bool, len(members))
+	for _, member := range members {
+		inside[member] = true
+	}
+
+	start := members[0]
+	previous := make(map[string]string)
+	queue := []string{start}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, next := range calledKeys(graph[node]) {
+			if !inside[next] {
+				continue
+			}
+
+			if next == start {
+				// Walk back to the start to build the path
+				path := []string{node}
+				for path[0] != start {
+					path = append([]string{previous[path[0]]}, path...)
+				}
+
+				return append(path, start)
+			}
+
+			if _, seen := previous[next]; !seen {
+				previous[next] = node
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return members
+}
+
+// processCycles Report the cycles of calls between components
+func processCycles() {
+	graph := componentGraph()
+
+	fmt.Fprintf(cycleWriter, "Cycles of calls between components\n")
+
+	for _, members := range stronglyConnected(graph) {
+		names := make([]string, 0, len(members))
+		for _, member := range members {
+			names = append(names, xref[member].name)
+		}
+
+		cycleCt++
+		signature := strings.Join(names, ", ")
+		fmt.Fprintf(cycleWriter, "%s%s\n", cyclePrefix, signature)
+
+		// Without a baseline every cycle is new
+		if _, found := cycleBaseline[strings.ToLower(signature)]; !found {
+			newCycles = append(newCycles, signature)
+		}
+
+		// Show the calls making up one cycle through the components
+		path := shortestCycle(graph, members)
+
+		for step := 0; step < len(path)-1; step++ {
+			sites := make([]string, 0)
+			for _, site := range graph[path[step]][path[step+1]] {
+				sites = append(sites, fmt.Sprintf("%s:%d (%s)", site.fileName, site.line, site.method))
+			}
+
+			sort.Strings(sites)
+			fmt.Fprintf(cycleWriter, "    %s -> %s at %s\n", xref[path[step]].name, xref[path[step+1]].name, strings.Join(sites, ", "))
+		}
+	}
+
+	for _, signature := range newCycles {
+		fmt.Fprintf(logWriter, "New cycle not in the baseline: %s\n", signature)
+	}
+}
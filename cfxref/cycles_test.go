@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// callGraph Build a graph of calls between components from the components each one calls
+func callGraph(calls map[string][]string) map[string]map[string][]callSite {
+	graph := make(map[string]map[string][]callSite)
+
+	for caller, called := range calls {
+		graph[caller] = make(map[string][]callSite)
+
+		for _, callee := range called {
+			graph[caller][callee] = []callSite{{fileName: caller + ".cfc", line: 1, method: "run"}}
+		}
+	}
+
+	return graph
+}
+
+func TestStronglyConnected(t *testing.T) {
+	a := assert.New(t)
+
+	// a <-> b, c -> d -> e -> c, e -> f, and g calling into the cycles without being part of one
+	graph := callGraph(map[string][]string{
+		"/a": {"/b"},
+		"/b": {"/a", "/c"},
+		"/c": {"/d"},
+		"/d": {"/e"},
+		"/e": {"/c", "/f"},
+		"/g": {"/a", "/e"},
+	})
+
+	a.Equal([][]string{{"/a", "/b"}, {"/c", "/d", "/e"}}, stronglyConnected(graph))
+	a.Equal([]string{"/c", "/d", "/e", "/c"}, shortestCycle(graph, []string{"/c", "/d", "/e"}))
+
+	// Nothing calls back
+	a.Empty(stronglyConnected(callGraph(map[string][]string{"/a": {"/b"}, "/b": {"/c"}})))
+}
+
+func TestProcessCyclesBaseline(t *testing.T) {
+	a := assert.New(t)
+	savedXref, savedBaseline, savedCycles, savedLog := xref, cycleBaseline, cycleWriter, logWriter
+	t.Cleanup(func() {
+		xref, cycleBaseline, cycleWriter, logWriter = savedXref, savedBaseline, savedCycles, savedLog
+		cycleCt, newCycles = 0, make([]string, 0)
+	})
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if !a.NoError(err) {
+		return
+	}
+
+	defer devNull.Close()
+	cycleWriter, logWriter = devNull, devNull
+
+	xref = map[string]compDef{
+		"/a": {name: "/A", funcs: map[string]funcDef{"run": {name: "run",
+			usedBy: map[string]funcUsage{"/b.cfc": {cleanName: "/B.cfc", useLines: []int{3}}}}}},
+		"/b": {name: "/B", funcs: map[string]funcDef{"run": {name: "run",
+			usedBy: map[string]funcUsage{"/a.cfc": {cleanName: "/A.cfc", useLines: []int{5}}}}}},
+	}
+
+	// Without a baseline every cycle is new
+	cycleBaseline, cycleCt, newCycles = nil, 0, make([]string, 0)
+	processCycles()
+	a.Equal([]string{"/A, /B"}, newCycles)
+
+	// The cycles in the baseline are not new
+	cycleBaseline, cycleCt, newCycles = map[string]interface{}{"/a, /b": nil}, 0, make([]string, 0)
+	processCycles()
+	a.Equal(1, cycleCt)
+	a.Empty(newCycles)
+}
@@ -0,0 +1,664 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Regular expression for the attributes of a tag or script component (script values may be unquoted)
+var regexpAttr = regexp.MustCompile(`([\w:]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([\w.]+))`)
+
+// Regular expression for the start of a script component with its attributes
+var regexpScriptComp = regexp.MustCompile(`(?i)^\s*component\b([^{]*)`)
+
+// Regular expression for the default value of a script function argument, with the value in group 1, 2 or 3
+var regexpDefault = regexp.MustCompile(`^\s*(?:"([^"]*)"|'([^']*)'|(\S+))`)
+
+// Regular expression for the access and return type before a script function
+var regexpScriptSig = regexp.MustCompile(`(?i)^\s*(?:(public|private|package|remote)\s+)?(?:([\w.\[\]]+)\s+)?function\s`)
+
+// Output directory for the documentation (empty when not generating documentation)
+var docsDir string = ""
+
+// Attributes of the cfcomponent tags (or script component) by component key
+var componentAttrs = make(map[string]map[string]string, 1000)
+
+// Documentation tracking for the file currently being scanned, since tags and script statements may span lines
+type docState struct {
+	tag        string    // Text of a tag that is not closed yet
+	statement  string    // Text of a script component or function statement up to its opening brace
+	collecting bool      // Collecting the lines of a script statement
+	component  bool      // The statement being collected is the component statement
+	started    bool      // The statement started on the current line, which has already been collected
+	mapName    string    // Component key of the file
+	scope      funcScope // The function of the statement being collected
+	comment    string    // Text of the last /** */ comment, which documents the statement after it
+}
+
+// recordDocs Capture the documentation attributes for components, functions and arguments
+// state: Documentation tracking for the file
+// scope: Function currently being scanned
+// fileName: Full name of the file being scanned
+// code: Text of the line with the comments removed
+// scriptMode: The line is script rather than tags
+func recordDocs(state *docState, scope funcScope, fileName string, code string, scriptMode bool) {
+	state.mapName = strings.ToLower(removeSuffix(relativeName(fileName)))
+
+	if scriptMode {
+		// The attributes of a script component are on the component statement
+		if state.started {
+			state.started = false
+		} else if state.collecting {
+			state.collect(code)
+		} else if len(scope.name) == 0 {
+			if location := regexpScriptComp.FindStringIndex(code); location != nil {
+				state.collecting = true
+				state.component = true
+				state.statement = ""
+				state.collect(code[location[0]:])
+			}
+		}
+
+		// A documentation comment only applies to the statement right after it
+		if len(strings.TrimSpace(code)) > 0 && !state.collecting {
+			state.comment = ""
+		}
+
+		return
+	}
+
+	// Tags may span lines, so the text of a tag is kept until it's closed
+	text := state.tag + code
+	lower := strings.ToLower(text)
+	state.tag = ""
+
+	for offset := 0; offset < len(text); {
+		start, tag := nextDocTag(lower[offset:])
+
+		if start < 0 {
+			break
+		}
+
+		start += offset
+		end := strings.IndexByte(text[start:], '>')
+
+		if end < 0 {
+			state.tag = text[start:] + "\n"
+			break
+		}
+
+		attrs := parseAttributes(text[start : start+end])
+		offset = start + end
+
+		switch {
+		case tag == "<cfcomponent":
+			componentAttrs[state.mapName] = attrs
+		case len(scope.name) == 0:
+			// Functions and arguments outside a function are ignored
+		case tag == "<cffunction":
+			updateFunction(scope, attrs, nil)
+		default:
+			updateFunction(scope, nil, []map[string]string{attrs})
+		}
+	}
+}
+
+// nextDocTag Find the first component, function or argument tag in lower case text
+// returns the position of the tag and its name, or -1 when there isn't one
+func nextDocTag(lower string) (int, string) {
+	first := -1
+	name := ""
+
+	for _, tag := range []string{"<cfcomponent", "<cffunction", "<cfargument"} {
+		if start := indexTag(lower, tag); start >= 0 && (first < 0 || start < first) {
+			first = start
+			name = tag
+		}
+	}
+
+	return first, name
+}
+
+// tagText Get the text of a tag starting at a position through its closing > or the end of the line
+func tagText(code string, start int) string {
+	end := strings.IndexByte(code[start:], '>')
+
+	if end < 0 {
+		return code[start:]
+	}
+
+	return code[start : start+end]
+}
+
+// parseAttributes Build a map of the (lower case) attribute names to their values
+func parseAttributes(text string) map[string]string {
+	attrs := make(map[string]string)
+
+	for _, match := range regexpAttr.FindAllStringSubmatch(text, -1) {
+		attrs[strings.ToLower(match[1])] = match[2] + match[3] + match[4]
+	}
+
+	return attrs
+}
+
+// recordComment Keep the text of a /** */ comment to document the statement after it
+// text: Text of the comments closed on the line
+func (state *docState) recordComment(text string) {
+	if strings.HasPrefix(text, "*") {
+		state.comment = text
+	}
+}
+
+// recordScriptFunction Start collecting the signature of a script function, which may span lines
+// scope: The function just defined
+// code: Text of the line from the start of the function signature
+func (state *docState) recordScriptFunction(scope funcScope, code string) {
+	state.collecting = true
+	state.component = false
+	state.started = true
+	state.statement = ""
+	state.scope = scope
+	state.collect(code)
+}
+
+// collect Add a line to the script statement, which ends at its opening brace (or semicolon without a body)
+func (state *docState) collect(code string) {
+	quote := byte(0)
+	depth := 0
+
+	for index := 0; index < len(code); index++ {
+		switch {
+		case quote != 0:
+			if code[index] == quote {
+				quote = 0
+			}
+		case code[index] == '"' || code[index] == '\'':
+			quote = code[index]
+		case code[index] == '(':
+			depth++
+		case code[index] == ')':
+			depth--
+		case depth <= 0 && (code[index] == '{' || code[index] == ';'):
+			state.statement += code[:index]
+			state.finish()
+			return
+		}
+	}
+
+	state.statement += code + "\n"
+}
+
+// finish Record the attributes of the complete script statement
+func (state *docState) finish() {
+	tags := parseDocComment(state.comment)
+	statement := strings.TrimSpace(state.statement)
+	state.collecting = false
+	state.comment = ""
+
+	if state.component {
+		attrs := parseAttributes(statement[len("component"):])
+
+		if len(attrs["hint"]) == 0 && len(tags["hint"]) > 0 {
+			attrs["hint"] = tags["hint"]
+		}
+
+		componentAttrs[state.mapName] = attrs
+		return
+	}
+
+	attrs := make(map[string]string)
+
+	if match := regexpScriptSig.FindStringSubmatch(statement); match != nil {
+		attrs["access"] = strings.ToLower(match[1])
+		attrs["returntype"] = match[2]
+	}
+
+	// The metadata follows the arguments, i.e. function getName() hint="The name" output=false
+	open, end := argumentList(statement)
+
+	if end > 0 {
+		for name, value := range parseAttributes(statement[end+1:]) {
+			attrs[name] = value
+		}
+	}
+
+	if len(attrs["hint"]) == 0 && len(tags["hint"]) > 0 {
+		attrs["hint"] = tags["hint"]
+	}
+
+	args := make([]map[string]string, 0)
+
+	if end > 0 {
+		args = parseScriptArgs(statement[open+1 : end])
+	}
+
+	for _, arg := range args {
+		if len(arg["hint"]) == 0 && len(tags["@"+strings.ToLower(arg["name"])]) > 0 {
+			arg["hint"] = tags["@"+strings.ToLower(arg["name"])]
+		}
+	}
+
+	updateFunction(state.scope, attrs, args)
+}
+
+// parseDocComment Get the hint and the argument hints from a /** */ comment.  The text before the first @ tag
+// is the hint unless there is a @hint, and @name tags describe the arguments
+// returns the hint and the text of each tag by lower case name, starting with @
+func parseDocComment(text string) map[string]string {
+	tags := make(map[string]string)
+	name := "hint"
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "*"))
+
+		if strings.HasPrefix(line, "@") {
+			fields := strings.SplitN(line, " ", 2)
+			name = strings.ToLower(fields[0])
+			line = ""
+
+			if len(fields) > 1 {
+				line = strings.TrimSpace(fields[1])
+			}
+
+			if name == "@hint" {
+				name = "hint"
+				tags[name] = ""
+			}
+		}
+
+		if len(line) > 0 {
+			tags[name] = strings.TrimSpace(tags[name] + " " + line)
+		}
+	}
+
+	return tags
+}
+
+// argumentList Find the parentheses around the arguments of a script function signature
+// returns the positions of the opening and closing parentheses, or zeros when they aren't found
+func argumentList(code string) (int, int) {
+	open := strings.IndexByte(code, '(')
+
+	if open < 0 {
+		return 0, 0
+	}
+
+	depth := 0
+	quote := byte(0)
+
+	for index := open; index < len(code); index++ {
+		switch {
+		case quote != 0:
+			if code[index] == quote {
+				quote = 0
+			}
+		case code[index] == '"' || code[index] == '\'':
+			quote = code[index]
+		case code[index] == '(':
+			depth++
+		case code[index] == ')':
+			depth--
+
+			if depth == 0 {
+				return open, index
+			}
+		}
+	}
+
+	return 0, 0
+}
+
+// splitArguments Split a list of arguments at the commas that aren't in strings or brackets
+func splitArguments(text string) []string {
+	specs := make([]string, 0)
+	depth := 0
+	quote := byte(0)
+	start := 0
+
+	for index := 0; index < len(text); index++ {
+		switch {
+		case quote != 0:
+			if text[index] == quote {
+				quote = 0
+			}
+		case text[index] == '"' || text[index] == '\'':
+			quote = text[index]
+		case strings.IndexByte("([{", text[index]) >= 0:
+			depth++
+		case strings.IndexByte(")]}", text[index]) >= 0:
+			depth--
+		case text[index] == ',' && depth == 0:
+			specs = append(specs, text[start:index])
+			start = index + 1
+		}
+	}
+
+	return append(specs, text[start:])
+}
+
+// parseScriptArgs Get the arguments from a script function argument list such as
+// required numeric id hint="The member", string name=""
+func parseScriptArgs(text string) []map[string]string {
+	args := make([]map[string]string, 0)
+
+	for _, spec := range splitArguments(text) {
+		arg := make(map[string]string)
+		equals := strings.IndexByte(spec, '=')
+		lead := spec
+
+		if equals >= 0 {
+			lead = spec[:equals]
+		}
+
+		words := strings.Fields(lead)
+
+		if len(words) > 0 && strings.EqualFold(words[0], "required") {
+			arg["required"] = "true"
+			words = words[1:]
+		}
+
+		metadata := ""
+
+		if equals >= 0 && (len(words) > 2 || (len(words) == 2 && isArgumentAttribute(words[1]))) {
+			// The word before the equals starts the metadata rather than being the name
+			metadata = words[len(words)-1] + spec[equals:]
+			words = words[:len(words)-1]
+		} else if equals >= 0 {
+			// The default value comes before any metadata
+			if value := regexpDefault.FindStringSubmatch(spec[equals+1:]); value != nil {
+				arg["default"] = value[1] + value[2] + value[3]
+				metadata = spec[equals+1+len(value[0]):]
+			}
+		}
+
+		if len(words) == 0 {
+			continue
+		}
+
+		for name, value := range parseAttributes(metadata) {
+			arg[name] = value
+		}
+
+		if len(words) > 1 {
+			arg["type"] = words[0]
+		}
+
+		arg["name"] = words[len(words)-1]
+		args = append(args, arg)
+	}
+
+	return args
+}
+
+// isArgumentAttribute Check if a word is an argument attribute rather than the name of an argument
+func isArgumentAttribute(word string) bool {
+	switch strings.ToLower(word) {
+	case "hint", "displayname", "restargsource", "restargname":
+		return true
+	}
+
+	return false
+}
+
+// updateFunction Add the attributes and arguments to the definition of the current function
+func updateFunction(scope funcScope, attrs map[string]string, args []map[string]string) {
+	functions := xref[scope.mapName].funcs
+	definition, found := functions[scope.funcKey]
+
+	if !found {
+		return
+	}
+
+	if attrs != nil {
+		definition.attrs = attrs
+	}
+
+	definition.args = append(definition.args, args...)
+	functions[scope.funcKey] = definition
+}
+
+// generateDocs Write the HTML and Markdown documentation for each component
+func generateDocs() error {
+	if err := os.MkdirAll(docsDir, 0755); err != nil {
+		return err
+	}
+
+	// Document the components in order
+	keys := make([]string, 0, len(xref))
+	for key := range xref {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var htmlIndex strings.Builder
+	var mdIndex strings.Builder
+
+	fmt.Fprintf(&htmlIndex, "<html><head><title>Components</title></head><body>\n<h1>Components</h1>\n<ul>\n")
+	fmt.Fprintf(&mdIndex, "# Components\n\n")
+
+	for _, key := range keys {
+		component := xref[key]
+
+		// Functions defined in pages are not components
+		if !strings.EqualFold(filepath.Ext(component.fileName), ".cfc") {
+			continue
+		}
+		attrs := componentAttrs[key]
+		baseName := docFileName(component.name)
+
+		fmt.Fprintf(&htmlIndex, "<li><a href=\"%s.html\">%s</a> %s</li>\n",
+			baseName, html.EscapeString(component.name), html.EscapeString(attrs["hint"]))
+		fmt.Fprintf(&mdIndex, "- [%s](%s.md) %s\n", component.name, baseName, attrs["hint"])
+
+		pageName := filepath.Join(docsDir, filepath.FromSlash(baseName))
+
+		if err := os.MkdirAll(filepath.Dir(pageName), 0755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(pageName+".html", []byte(componentHTML(component, attrs)), 0644); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(pageName+".md", []byte(componentMarkdown(component, attrs)), 0644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(&htmlIndex, "</ul>\n</body></html>\n")
+
+	if err := os.WriteFile(filepath.Join(docsDir, "index.html"), []byte(htmlIndex.String()), 0644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(docsDir, "index.md"), []byte(mdIndex.String()), 0644)
+}
+
+// docFileName Build the documentation file name (without suffix) for a component, relative to the docs directory.
+// The directories of the component are kept so /a/b_c and /a_b/c don't share a page
+func docFileName(componentName string) string {
+	return strings.TrimPrefix(patchName(componentName), "/")
+}
+
+// sortedFunctions Get the functions of a component in name order
+func sortedFunctions(component compDef) []funcDef {
+	functions := make([]funcDef, 0, len(component.funcs))
+	for _, function := range component.funcs {
+		functions = append(functions, function)
+	}
+
+	sort.Slice(functions, func(i, j int) bool {
+		return strings.ToLower(functions[i].name) < strings.ToLower(functions[j].name)
+	})
+
+	return functions
+}
+
+// signature Build the signature of a function, i.e. public string getName(required numeric id)
+func signature(function funcDef) string {
+	args := make([]string, 0, len(function.args))
+
+	for _, arg := range function.args {
+		spec := arg["name"]
+
+		if len(arg["type"]) > 0 {
+			spec = arg["type"] + " " + spec
+		}
+
+		if strings.EqualFold(arg["required"], "true") || strings.EqualFold(arg["required"], "yes") {
+			spec = "required " + spec
+		}
+
+		if _, found := arg["default"]; found {
+			spec += "=\"" + arg["default"] + "\""
+		}
+
+		args = append(args, spec)
+	}
+
+	prefix := strings.TrimSpace(function.attrs["access"] + " " + function.attrs["returntype"])
+
+	return strings.TrimSpace(prefix + " " + function.name + "(" + strings.Join(args, ", ") + ")")
+}
+
+// callers Get the callers of a function as sorted file names with their lines
+func callers(function funcDef) []string {
+	result := make([]string, 0, len(function.usedBy))
+
+	for _, usage := range function.usedBy {
+		lines := append([]int(nil), usage.useLines...)
+		sort.Ints(lines)
+		result = append(result, fmt.Sprintf("%s %v", usage.cleanName, lines))
+	}
+
+	if len(result) == 0 && function.implicit {
+		result = append(result, "Not called")
+	} else if len(result) == 0 && len(function.provider) > 0 {
+		result = append(result, "Called by "+function.provider)
+	} else if len(result) == 0 {
+		result = append(result, "Not called (orphan)")
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// componentHTML Build the HTML documentation page for a component
+func componentHTML(component compDef, attrs map[string]string) string {
+	var page strings.Builder
+	name := html.EscapeString(component.name)
+
+	fmt.Fprintf(&page, "<html><head><title>%s</title></head><body>\n", name)
+	fmt.Fprintf(&page, "<p><a href=\"%sindex.html\">All components</a></p>\n", strings.Repeat("../", strings.Count(docFileName(component.name), "/")))
+	fmt.Fprintf(&page, "<h1>%s</h1>\n", name)
+
+	if len(attrs["displayname"]) > 0 {
+		fmt.Fprintf(&page, "<h2>%s</h2>\n", html.EscapeString(attrs["displayname"]))
+	}
+
+	if len(attrs["hint"]) > 0 {
+		fmt.Fprintf(&page, "<p>%s</p>\n", html.EscapeString(attrs["hint"]))
+	}
+
+	for _, function := range sortedFunctions(component) {
+		fmt.Fprintf(&page, "<h3 id=\"%s\">%s</h3>\n", html.EscapeString(function.name), html.EscapeString(function.name))
+		fmt.Fprintf(&page, "<pre>%s</pre>\n", html.EscapeString(signature(function)))
+
+		if len(function.attrs["displayname"]) > 0 {
+			fmt.Fprintf(&page, "<p><b>%s</b></p>\n", html.EscapeString(function.attrs["displayname"]))
+		}
+
+		if len(function.attrs["hint"]) > 0 {
+			fmt.Fprintf(&page, "<p>%s</p>\n", html.EscapeString(function.attrs["hint"]))
+		}
+
+		fmt.Fprintf(&page, "<p>Access: %s</p>\n", html.EscapeString(accessLevel(function)))
+
+		if len(function.args) > 0 {
+			fmt.Fprintf(&page, "<table border=\"1\">\n<tr><th>Argument</th><th>Type</th><th>Required</th><th>Default</th><th>Hint</th></tr>\n")
+			for _, arg := range function.args {
+				fmt.Fprintf(&page, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+					html.EscapeString(arg["name"]), html.EscapeString(arg["type"]), html.EscapeString(arg["required"]),
+					html.EscapeString(arg["default"]), html.EscapeString(arg["hint"]))
+			}
+			fmt.Fprintf(&page, "</table>\n")
+		}
+
+		fmt.Fprintf(&page, "<h4>Called from</h4>\n<ul>\n")
+		for _, caller := range callers(function) {
+			fmt.Fprintf(&page, "<li>%s</li>\n", html.EscapeString(caller))
+		}
+		fmt.Fprintf(&page, "</ul>\n")
+	}
+
+	fmt.Fprintf(&page, "</body></html>\n")
+
+	return page.String()
+}
+
+// componentMarkdown Build the Markdown documentation page for a component
+func componentMarkdown(component compDef, attrs map[string]string) string {
+	var page strings.Builder
+
+	fmt.Fprintf(&page, "# %s\n\n", component.name)
+
+	if len(attrs["displayname"]) > 0 {
+		fmt.Fprintf(&page, "**%s**\n\n", attrs["displayname"])
+	}
+
+	if len(attrs["hint"]) > 0 {
+		fmt.Fprintf(&page, "%s\n\n", attrs["hint"])
+	}
+
+	for _, function := range sortedFunctions(component) {
+		fmt.Fprintf(&page, "## %s\n\n```\n%s\n```\n\n", function.name, signature(function))
+
+		if len(function.attrs["displayname"]) > 0 {
+			fmt.Fprintf(&page, "**%s**\n\n", function.attrs["displayname"])
+		}
+
+		if len(function.attrs["hint"]) > 0 {
+			fmt.Fprintf(&page, "%s\n\n", function.attrs["hint"])
+		}
+
+		fmt.Fprintf(&page, "Access: %s\n\n", accessLevel(function))
+
+		if len(function.args) > 0 {
+			fmt.Fprintf(&page, "| Argument | Type | Required | Default | Hint |\n|---|---|---|---|---|\n")
+			for _, arg := range function.args {
+				fmt.Fprintf(&page, "| %s | %s | %s | %s | %s |\n", markdownCell(arg["name"]), markdownCell(arg["type"]),
+					markdownCell(arg["required"]), markdownCell(arg["default"]), markdownCell(arg["hint"]))
+			}
+			fmt.Fprintf(&page, "\n")
+		}
+
+		fmt.Fprintf(&page, "### Called from\n\n")
+		for _, caller := range callers(function) {
+			fmt.Fprintf(&page, "- %s\n", caller)
+		}
+		fmt.Fprintf(&page, "\n")
+	}
+
+	return page.String()
+}
+
+// accessLevel Get the access level of a function (public when not specified)
+func accessLevel(function funcDef) string {
+	if len(function.attrs["access"]) == 0 {
+		return "public"
+	}
+
+	return strings.ToLower(function.attrs["access"])
+}
+
+// markdownCell Escape a value for use in a Markdown table
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useXref Give a test an empty cross reference, restoring the previous one after it
+func useXref(t *testing.T) {
+	savedXref, savedAttrs, savedFuncs := xref, componentAttrs, totalFuncs
+	t.Cleanup(func() {
+		xref, componentAttrs, totalFuncs = savedXref, savedAttrs, savedFuncs
+	})
+
+	xref = make(map[string]compDef)
+	componentAttrs = make(map[string]map[string]string)
+}
+
+func TestParseScriptArgs(t *testing.T) {
+	a := assert.New(t)
+
+	args := parseScriptArgs(`required numeric id hint="The member, by id", string name="a,b" displayname="Name", sort = 'asc', struct opts={}`)
+
+	if a.Len(args, 4) {
+		a.Equal(map[string]string{"required": "true", "type": "numeric", "name": "id", "hint": "The member, by id"}, args[0])
+		a.Equal(map[string]string{"type": "string", "name": "name", "default": "a,b", "displayname": "Name"}, args[1])
+		a.Equal(map[string]string{"name": "sort", "default": "asc"}, args[2])
+		a.Equal(map[string]string{"type": "struct", "name": "opts", "default": "{}"}, args[3])
+	}
+
+	args = parseScriptArgs(`id hint="The member"`)
+
+	if a.Len(args, 1) {
+		a.Equal(map[string]string{"name": "id", "hint": "The member"}, args[0])
+	}
+
+	a.Empty(parseScriptArgs(" "))
+}
+
+func TestParseDocComment(t *testing.T) {
+	tags := parseDocComment("*\n * Gets the name\n * of a member\n * @id The member\n *   by id\n * @returns The name\n ")
+
+	assert.Equal(t, map[string]string{"hint": "Gets the name of a member", "@id": "The member by id", "@returns": "The name"}, tags)
+	assert.Equal(t, "The hint", parseDocComment("* Ignored\n * @hint The hint")["hint"])
+}
+
+func TestRecordScriptDocs(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	useXref(t)
+
+	fileName := "/web/cfc/Members.cfc"
+	docs := docState{}
+	lines := []string{
+		`component hint="Members"`,
+		`    accessors=true {`,
+		``,
+		`    public string function getName(`,
+		`        required numeric id,`,
+		`        boolean full=false hint="Include the title"`,
+		`    ) output=false {`,
+		`    }`,
+	}
+
+	for index, line := range lines {
+		scope := funcScope{}
+
+		// The documentation comment closes on the blank line
+		if index == 2 {
+			docs.recordComment("*\n     * Gets the name\n     * @id The member\n     ")
+		}
+
+		if index == 3 {
+			scope = defineFunction(fileName, "getName", index+1, true)
+			docs.recordScriptFunction(scope, line[4:])
+		}
+
+		recordDocs(&docs, scope, fileName, line, true)
+	}
+
+	a.Equal(map[string]string{"hint": "Members", "accessors": "true"}, componentAttrs["/cfc/members"])
+
+	function := xref["/cfc/members"].funcs["getname"]
+	a.Equal(map[string]string{"access": "public", "returntype": "string", "output": "false", "hint": "Gets the name"}, function.attrs)
+
+	if a.Len(function.args, 2) {
+		a.Equal(map[string]string{"required": "true", "type": "numeric", "name": "id", "hint": "The member"}, function.args[0])
+		a.Equal(map[string]string{"type": "boolean", "name": "full", "default": "false", "hint": "Include the title"}, function.args[1])
+	}
+}
+
+func TestRecordTagDocs(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	useXref(t)
+
+	fileName := "/web/cfc/Members.cfc"
+	docs := docState{}
+	scope := funcScope{}
+	lines := []string{
+		`<cfcomponent hint="Members"`,
+		`    output="false">`,
+		`<cffunction name="save"`,
+		`    access="remote" hint="Saves">`,
+		`    <cfargument name="id" type="numeric"`,
+		`        hint="The member"><cfargument name="name">`,
+	}
+
+	for index, line := range lines {
+		if index == 2 {
+			scope = defineFunction(fileName, "save", index+1, false)
+		}
+
+		recordDocs(&docs, scope, fileName, line, false)
+	}
+
+	a.Equal(map[string]string{"hint": "Members", "output": "false"}, componentAttrs["/cfc/members"])
+
+	function := xref["/cfc/members"].funcs["save"]
+	a.Equal(map[string]string{"name": "save", "access": "remote", "hint": "Saves"}, function.attrs)
+
+	if a.Len(function.args, 2) {
+		a.Equal(map[string]string{"name": "id", "type": "numeric", "hint": "The member"}, function.args[0])
+		a.Equal(map[string]string{"name": "name"}, function.args[1])
+	}
+}
+
+func TestGenerateDocsComponentsOnly(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	useXref(t)
+
+	savedDir := docsDir
+	defer func() { docsDir = savedDir }()
+	docsDir = t.TempDir()
+
+	defineFunction("/web/cfc/Members.cfc", "getName", 1, false)
+	defineFunction("/web/index.cfm", "helper", 1, false)
+
+	a.NoError(generateDocs())
+
+	a.FileExists(filepath.Join(docsDir, "cfc", "Members.html"))
+	a.FileExists(filepath.Join(docsDir, "cfc", "Members.md"))
+	a.NoFileExists(filepath.Join(docsDir, "index.html.html"))
+
+	entries, err := os.ReadDir(docsDir)
+	a.NoError(err)
+	a.Len(entries, 3)
+}
+
+func TestGenerateDocsDirectories(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	useXref(t)
+
+	savedDir := docsDir
+	defer func() { docsDir = savedDir }()
+	docsDir = t.TempDir()
+
+	// The names would be the same with the directories flattened
+	defineFunction("/web/a/b_c.cfc", "first", 1, false)
+	defineFunction("/web/a_b/c.cfc", "second", 1, false)
+
+	a.NoError(generateDocs())
+
+	first, err := os.ReadFile(filepath.Join(docsDir, "a", "b_c.html"))
+	if a.NoError(err) {
+		a.Contains(string(first), "first")
+		a.Contains(string(first), `<a href="../index.html">`)
+	}
+
+	second, err := os.ReadFile(filepath.Join(docsDir, "a_b", "c.md"))
+	if a.NoError(err) {
+		a.Contains(string(second), "second")
+	}
+
+	index, err := os.ReadFile(filepath.Join(docsDir, "index.html"))
+	if a.NoError(err) {
+		a.Contains(string(index), `href="a/b_c.html"`)
+		a.Contains(string(index), `href="a_b/c.html"`)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings of the source files
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encoding1252    = "windows-1252"
+	encodingLatin1  = "iso-8859-1"
+	encodingUTF8BOM = "utf-8 with a byte order mark" // Only recorded for the files that can't be patched
+)
+
+// Byte order marks
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Number of bytes looked at to recognize UTF-16 without a byte order mark
+const encodingSample = 4096
+
+// Characters of Windows-1252 for the bytes 0x80 to 0x9F, which are control characters in ISO-8859-1
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021, 0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+// Encodings set in the configuration by lower case directory relative to the root
+var dirEncodings = make(map[string]string, 10)
+
+// Files that are not plain UTF-8, with their encoding, by full file name
+var fileEncodings = make(map[string]string, 100)
+
+// Number of files whose encoding was guessed
+var guessedEncodingCt int = 0
+
+// parseEncodings Get the encodings of the directories from the configuration
+// returns false if the configuration is invalid
+func parseEncodings(val interface{}) bool {
+	passed := true
+	specs := val.(map[string]interface{})
+
+	for dir, setting := range specs {
+		encoding := strings.ToLower(setting.(string))
+
+		switch encoding {
+		case encodingUTF8, encodingUTF16LE, encodingUTF16BE, encoding1252, encodingLatin1:
+			dirEncodings[strings.ToLower(cleanDirName(dir))] = encoding
+		default:
+			fmt.Fprintf(os.Stderr, "Invalid %s encoding '%s' for %s\n", KwEncodings, setting, dir)
+			passed = false
+		}
+	}
+
+	return passed
+}
+
+// readSource Read a source file and decode it to UTF-8.  A byte order mark decides the encoding, then the
+// encoding configured for the directory, then the content
+func readSource(fileName string) ([]byte, error) {
+	content, err := os.ReadFile(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	delete(fileEncodings, fileName)
+
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		// The columns found are after the byte order mark, which the lines of a patch would include
+		fileEncodings[fileName] = encodingUTF8BOM
+		return content[len(bomUTF8):], nil
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return decodeSource(fileName, content[len(bomUTF16LE):], encodingUTF16LE), nil
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return decodeSource(fileName, content[len(bomUTF16BE):], encodingUTF16BE), nil
+	}
+
+	if encoding := configuredEncoding(fileName); len(encoding) > 0 {
+		if encoding == encodingUTF8 && !utf8.Valid(content) {
+			fmt.Fprintf(logWriter, "The file %s is set to %s in %s but is not valid %s\n", relativeName(fileName), encoding, KwEncodings, encoding)
+		}
+
+		return decodeSource(fileName, content, encoding), nil
+	}
+
+	// UTF-16 text in ASCII is also valid UTF-8, so it is checked first
+	encoding := guessUTF16(content)
+
+	if len(encoding) == 0 && utf8.Valid(content) {
+		return content, nil
+	}
+
+	// Without a byte order mark, the encoding can only be guessed
+	if len(encoding) == 0 {
+		encoding = encoding1252
+	}
+
+	guessedEncodingCt++
+	fmt.Fprintf(logWriter, "The file %s has no byte order mark and is not plain UTF-8, it was read as %s (set the encoding of its directory in %s)\n",
+		relativeName(fileName), encoding, KwEncodings)
+
+	return decodeSource(fileName, content, encoding), nil
+}
+
+// configuredEncoding Get the encoding set for the directory of a file, the longest directory matching wins
+func configuredEncoding(fileName string) string {
+	relName := strings.ToLower(relativeName(fileName))
+	plainName := relName[len(qualifier(relName)):]
+
+	dirs := make([]string, 0, len(dirEncodings))
+	for dir := range dirEncodings {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+
+	for _, dir := range dirs {
+		prefix := strings.TrimSuffix(dir, "/") + "/"
+
+		if strings.HasPrefix(relName, prefix) || strings.HasPrefix(plainName, prefix) {
+			return dirEncodings[dir]
+		}
+	}
+
+	return ""
+}
+
+// guessUTF16 Check if content without a byte order mark looks like UTF-16, where ASCII text has a zero in every other byte
+// returns the UTF-16 encoding, empty when it doesn't look like UTF-16
+func guessUTF16(content []byte) string {
+	sample := content
+	if len(sample) > encodingSample {
+		sample = sample[:encodingSample]
+	}
+
+	evenZeros, oddZeros := 0, 0
+	for index, value := range sample {
+		if value != 0 {
+			continue
+		}
+
+		if index%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	// Most of the characters should have the zero
+	half := len(sample) / 2
+
+	switch {
+	case oddZeros > half*3/4 && evenZeros < half/10:
+		return encodingUTF16LE
+	case evenZeros > half*3/4 && oddZeros < half/10:
+		return encodingUTF16BE
+	}
+
+	return ""
+}
+
+// decodeSource Convert content to UTF-8, remembering the files that were not UTF-8
+func decodeSource(fileName string, content []byte, encoding string) []byte {
+	if encoding == encodingUTF8 {
+		return content
+	}
+
+	fileEncodings[fileName] = encoding
+
+	switch encoding {
+	case encodingUTF16LE, encodingUTF16BE:
+		units := make([]uint16, 0, len(content)/2)
+
+		for index := 0; index+1 < len(content); index += 2 {
+			if encoding == encodingUTF16LE {
+				units = append(units, uint16(content[index])|uint16(content[index+1])<<8)
+			} else {
+				units = append(units, uint16(content[index])<<8|uint16(content[index+1]))
+			}
+		}
+
+		return []byte(string(utf16.Decode(units)))
+	}
+
+	// Windows-1252 and ISO-8859-1 have one byte per character
+	var result bytes.Buffer
+	result.Grow(len(content))
+
+	for _, value := range content {
+		if value >= 0x80 && value < 0xA0 && encoding == encoding1252 {
+			result.WriteRune(windows1252[value-0x80])
+		} else {
+			result.WriteRune(rune(value))
+		}
+	}
+
+	return result.Bytes()
+}
+
+// checkPatchable Check a file can be patched, which needs the lines and columns of the file itself
+func checkPatchable(fileName string) error {
+	if encoding, found := fileEncodings[fileName]; found {
+		return fmt.Errorf("the file %s is %s, convert it to UTF-8 without a byte order mark before changing it", relativeName(fileName), encoding)
+	}
+
+	return nil
+}
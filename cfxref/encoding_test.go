@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSource(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+	useRoot(t, dir)
+	savedDirs, savedLog, savedCt := dirEncodings, logWriter, guessedEncodingCt
+	t.Cleanup(func() {
+		dirEncodings, logWriter, guessedEncodingCt = savedDirs, savedLog, savedCt
+	})
+
+	logFile, err := os.Create(filepath.Join(dir, "log.txt"))
+	if !a.NoError(err) {
+		return
+	}
+
+	defer logFile.Close()
+	logWriter, guessedEncodingCt = logFile, 0
+	dirEncodings = map[string]string{"/latin": encodingLatin1}
+
+	text := `<cfset name = "Café ™">`
+	utf16le := func(bom bool) []byte {
+		content := make([]byte, 0)
+		if bom {
+			content = append(content, bomUTF16LE...)
+		}
+
+		for _, unit := range utf16.Encode([]rune(text)) {
+			content = append(content, byte(unit), byte(unit>>8))
+		}
+
+		return content
+	}
+
+	files := []struct {
+		name     string
+		content  []byte
+		expected string
+		encoding string
+	}{
+		{"utf8.cfm", []byte(text), text, ""},
+		{"bom8.cfm", append(append([]byte{}, bomUTF8...), text...), text, encodingUTF8BOM},
+		{"bom16.cfm", utf16le(true), text, encodingUTF16LE},
+		{"guess16.cfm", utf16le(false), text, encodingUTF16LE},
+		{"cp1252.cfm", []byte("<cfset name = \"Caf\xe9 \x99\">"), text, encoding1252},
+		{"latin/page.cfm", []byte("<cfset name = \"Caf\xe9\">"), `<cfset name = "Café">`, encodingLatin1},
+	}
+
+	for _, file := range files {
+		fileName := filepath.Join(dir, filepath.FromSlash(file.name))
+		a.NoError(os.MkdirAll(filepath.Dir(fileName), 0755))
+		a.NoError(os.WriteFile(fileName, file.content, 0644))
+
+		content, err := readSource(fileName)
+
+		if a.NoError(err, file.name) {
+			a.Equal(file.expected, string(content), file.name)
+			a.Equal(file.encoding, fileEncodings[fileName], file.name)
+		}
+
+		// Only UTF-8 files without a byte order mark can be patched
+		a.Equal(len(file.encoding) == 0, checkPatchable(fileName) == nil, file.name)
+	}
+
+	// The files without a byte order mark or a configured encoding were guessed
+	a.Equal(2, guessedEncodingCt)
+
+	// The byte order mark would shift the columns of the first line
+	_, err = readLines(filepath.Join(dir, "bom8.cfm"))
+	if a.Error(err) {
+		a.Contains(err.Error(), "byte order mark")
+	}
+
+	_, err = readLines(filepath.Join(dir, "utf8.cfm"))
+	a.NoError(err)
+}
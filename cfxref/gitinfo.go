@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Git settings
+var gitRange string = ""                    // Revision range restricting the reports to the files changed in it
+var gitAge bool = false                     // Annotate orphans with the last commit that touched them
+var changedFiles map[string]interface{}     // Lower case names (relative to the root) of the files changed in the range
+var orphanAges = make(map[string]gitAuthor) // Last change to each orphan by component.function
+var blames = make(map[string]fileBlame)     // The blame of each file with orphans, by full file name
+
+// The last commit that touched some lines
+type gitAuthor struct {
+	author string    // Author of the commit
+	when   time.Time // Commit author time
+}
+
+// The blame of a file, found once for all the orphans in it
+type fileBlame struct {
+	lines []gitAuthor // Last commit touching each line (1 based, the first entry is not used)
+	err   error       // Error running git blame
+}
+
+// runGit Run a git command in a root directory and return its output
+func runGit(dir string, args ...string) ([]byte, error) {
+	command := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+
+	output, err := command.Output()
+
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %s %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+// loadChangedFiles Get the files changed in the revision range in each root
+func loadChangedFiles() error {
+	changedFiles = make(map[string]interface{})
+
+	for _, root := range roots {
+		output, err := runGit(root.dir, "diff", "--name-only", "--relative", gitRange, "--")
+
+		if err != nil {
+			return err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+
+		for scanner.Scan() {
+			if name := strings.TrimSpace(scanner.Text()); len(name) > 0 {
+				changedFiles[strings.ToLower(relativeName(root.dir+cleanDirName(name)))] = nil
+			}
+		}
+
+		if err = scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(logWriter, "There are %d files changed in %s\n", len(changedFiles), gitRange)
+
+	return nil
+}
+
+// inChangeSet Check if a file (relative to the root) should be reported on, which is every file
+// unless a revision range was given
+func inChangeSet(relName string) bool {
+	if changedFiles == nil {
+		return true
+	}
+
+	_, found := changedFiles[strings.ToLower(strings.ReplaceAll(relName, `\`, "/"))]
+
+	return found
+}
+
+// lastChange Find the most recent commit touching the lines of a function, running git blame once per file
+// fileName: Full name of the file
+// first: First line of the function
+// last: Last line of the function
+func lastChange(fileName string, first int, last int) (gitAuthor, error) {
+	result := gitAuthor{}
+	blame, found := blames[fileName]
+
+	if root := rootOf(fileName); !found && root == nil {
+		blame.err = fmt.Errorf("the file %s is not in a web root", fileName)
+		blames[fileName] = blame
+	} else if !found {
+		relName := strings.TrimPrefix(strings.ReplaceAll(fileName[len(root.dir):], `\`, "/"), "/")
+		output, err := runGit(root.dir, "blame", "--porcelain", "--", relName)
+
+		if err == nil {
+			blame.lines, err = parseBlame(output)
+		}
+
+		blame.err = err
+		blames[fileName] = blame
+	}
+
+	if blame.err != nil {
+		return result, blame.err
+	}
+
+	if first < 1 || last >= len(blame.lines) {
+		return result, fmt.Errorf("lines %d to %d are not in the blame of %s", first, last, fileName)
+	}
+
+	for _, line := range blame.lines[first : last+1] {
+		if line.when.After(result.when) {
+			result = line
+		}
+	}
+
+	return result, nil
+}
+
+// parseBlame Get the commit that last touched each line from git blame porcelain output
+// returns the author of each line, indexed from 1
+func parseBlame(output []byte) ([]gitAuthor, error) {
+	// The porcelain format gives the author details the first time each commit appears
+	commits := make(map[string]gitAuthor)
+	lineCommits := make([]string, 1)
+	commit := ""
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), len(scanBuff))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// The content of the line
+		case strings.HasPrefix(line, "author "):
+			author := commits[commit]
+			author.author = strings.TrimPrefix(line, "author ")
+			commits[commit] = author
+		case strings.HasPrefix(line, "author-time "):
+			seconds, err := strconv.ParseInt(strings.TrimPrefix(line, "author-time "), 10, 64)
+
+			if err != nil {
+				return nil, err
+			}
+
+			author := commits[commit]
+			author.when = time.Unix(seconds, 0)
+			commits[commit] = author
+		case len(fields) >= 3 && len(fields[0]) >= 40 && strings.Trim(fields[0], "0123456789abcdef") == "":
+			// A commit, its line in the original file and its line now
+			commit = fields[0]
+			lineNo, err := strconv.Atoi(fields[2])
+
+			if err != nil {
+				return nil, err
+			}
+
+			for len(lineCommits) <= lineNo {
+				lineCommits = append(lineCommits, "")
+			}
+
+			lineCommits[lineNo] = commit
+		}
+	}
+
+	lines := make([]gitAuthor, len(lineCommits))
+
+	for lineNo, commit := range lineCommits {
+		lines[lineNo] = commits[commit]
+	}
+
+	return lines, scanner.Err()
+}
+
+// processOrphanAges Find the last change to each orphan
+func processOrphanAges() {
+	// Files may have changed when watching
+	orphanAges = make(map[string]gitAuthor)
+	blames = make(map[string]fileBlame)
+
+	for componentName, functionNames := range orphans {
+		component := xref[strings.ToLower(componentName)]
+
+		for _, functionName := range functionNames {
+			function := component.funcs[strings.ToLower(functionName)]
+			age, err := lastChange(component.fileName, function.line, function.endLine)
+
+			if err != nil {
+				fmt.Fprintf(logWriter, "Unable to find the last change to %s.%s: %s\n", componentName, functionName, err)
+				continue
+			}
+
+			orphanAges[componentName+"."+functionName] = age
+		}
+	}
+}
+
+// oldestFirst Sort orphan function names (of one component) so the ones changed longest ago come first
+func oldestFirst(componentName string, functionNames []string) {
+	sort.SliceStable(functionNames, func(i, j int) bool {
+		return orphanAges[componentName+"."+functionNames[i]].when.Before(orphanAges[componentName+"."+functionNames[j]].when)
+	})
+}
+
+// displayOrphanAges Display the orphans with the components changed longest ago first, followed by the orphans
+// whose last change is unknown
+func displayOrphanAges() {
+	dated := make(map[string][]string)
+	unknown := make(map[string][]string)
+
+	for componentName, functionNames := range orphans {
+		for _, functionName := range functionNames {
+			if _, found := orphanAges[componentName+"."+functionName]; found {
+				dated[componentName] = append(dated[componentName], functionName)
+			} else {
+				unknown[componentName] = append(unknown[componentName], functionName)
+			}
+		}
+	}
+
+	componentNames := make([]string, 0, len(dated))
+	for componentName, functionNames := range dated {
+		componentNames = append(componentNames, componentName)
+		oldestFirst(componentName, functionNames)
+	}
+
+	sort.Strings(componentNames)
+	sort.SliceStable(componentNames, func(i, j int) bool {
+		first := componentNames[i] + "." + dated[componentNames[i]][0]
+		second := componentNames[j] + "." + dated[componentNames[j]][0]
+		return orphanAges[first].when.Before(orphanAges[second].when)
+	})
+
+	fmt.Fprintf(orphanWriter, "Orphaned functions and their component\n")
+	for _, componentName := range componentNames {
+		fmt.Fprintf(orphanWriter, "Component: %s\n", componentName)
+		for _, functionName := range dated[componentName] {
+			fmt.Fprintf(orphanWriter, "    %s%s\n", functionName, describeAge(componentName, functionName))
+		}
+	}
+
+	if len(unknown) == 0 {
+		return
+	}
+
+	componentNames = componentNames[:0]
+	for componentName, functionNames := range unknown {
+		componentNames = append(componentNames, componentName)
+		sort.Strings(functionNames)
+	}
+
+	sort.Strings(componentNames)
+
+	fmt.Fprintf(orphanWriter, "Orphaned functions whose last change is unknown\n")
+	for _, componentName := range componentNames {
+		fmt.Fprintf(orphanWriter, "Component: %s\n", componentName)
+		for _, functionName := range unknown[componentName] {
+			fmt.Fprintf(orphanWriter, "    %s\n", functionName)
+		}
+	}
+}
+
+// describeAge Build the annotation showing the last change to an orphan
+func describeAge(componentName string, functionName string) string {
+	age, found := orphanAges[componentName+"."+functionName]
+
+	if !found {
+		return ""
+	}
+
+	return fmt.Sprintf(" (last changed %s by %s)", age.when.Format("2006-01-02"), age.author)
+}
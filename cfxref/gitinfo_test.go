@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const blameOutput = `1111111111111111111111111111111111111111 1 1 2
+author Ann
+author-mail <ann@example.com>
+author-time 1600000000
+author-tz +0000
+summary Start
+filename Members.cfc
+	<cfcomponent>
+1111111111111111111111111111111111111111 2 2
+	<cffunction name="old">
+2222222222222222222222222222222222222222 3 3 1
+author Bob
+author-time 1700000000
+summary Change
+filename Members.cfc
+	</cffunction>
+`
+
+func TestParseBlame(t *testing.T) {
+	a := assert.New(t)
+	lines, err := parseBlame([]byte(blameOutput))
+
+	if a.NoError(err) && a.Len(lines, 4) {
+		a.Equal(gitAuthor{author: "Ann", when: time.Unix(1600000000, 0)}, lines[1])
+		a.Equal(gitAuthor{author: "Ann", when: time.Unix(1600000000, 0)}, lines[2])
+		a.Equal(gitAuthor{author: "Bob", when: time.Unix(1700000000, 0)}, lines[3])
+	}
+}
+
+func TestLastChange(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	savedBlames := blames
+	defer func() { blames = savedBlames }()
+
+	lines, _ := parseBlame([]byte(blameOutput))
+	blames = map[string]fileBlame{"/web/Members.cfc": {lines: lines}, "/web/New.cfc": {err: errors.New("no such path")}}
+
+	age, err := lastChange("/web/Members.cfc", 1, 2)
+	a.NoError(err)
+	a.Equal("Ann", age.author)
+
+	age, err = lastChange("/web/Members.cfc", 2, 3)
+	a.NoError(err)
+	a.Equal("Bob", age.author)
+
+	_, err = lastChange("/web/Members.cfc", 2, 4)
+	a.Error(err)
+
+	_, err = lastChange("/web/New.cfc", 1, 2)
+	a.Error(err)
+}
+
+func TestDisplayOrphanAges(t *testing.T) {
+	a := assert.New(t)
+	savedOrphans, savedAges, savedWriter := orphans, orphanAges, orphanWriter
+	defer func() { orphans, orphanAges, orphanWriter = savedOrphans, savedAges, savedWriter }()
+
+	fileName := t.TempDir() + "/orphans.txt"
+	writer, err := os.Create(fileName)
+	a.NoError(err)
+	orphanWriter = writer
+
+	orphans = map[string][]string{"/a": {"recent", "unknown"}, "/b": {"old"}, "/c": {"lost"}}
+	orphanAges = map[string]gitAuthor{
+		"/a.recent": {author: "Ann", when: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		"/b.old":    {author: "Bob", when: time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+
+	displayOrphanAges()
+	writer.Close()
+
+	output, err := os.ReadFile(fileName)
+	a.NoError(err)
+	a.Equal("Orphaned functions and their component\n"+
+		"Component: /b\n"+
+		"    old (last changed 2019-05-01 by Bob)\n"+
+		"Component: /a\n"+
+		"    recent (last changed 2024-05-01 by Ann)\n"+
+		"Orphaned functions whose last change is unknown\n"+
+		"Component: /a\n"+
+		"    unknown\n"+
+		"Component: /c\n"+
+		"    lost\n", string(output))
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"dacdb.com/GoCode/filecrc/utils"
+)
+
+// Files and component.method names given for the impact analysis, a name starting with @ is a file listing them
+var impactNames []string
+
+// A function or page in the caller tree
+type impactNode struct {
+	key     string // Unique key, compKey.funcKey for a function or the lower case file name for code outside of a function
+	label   string // Name to display
+	compKey string // Component key of a function
+	funcKey string // Function key of a function (empty for code outside of a function)
+	page    bool   // An entry page (cfm, JavaScript or HTML) rather than a function
+	lines   []int  // Lines the call is made from
+	file    string // Relative name of the file making the call
+}
+
+// impactAnalysis Display the tree of callers reaching the changed files and functions, and the entry pages affected
+func impactAnalysis() error {
+	names, err := expandImpactNames()
+
+	if err != nil {
+		return err
+	}
+
+	targets := make([]impactNode, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, impactTargets(name)...)
+	}
+
+	if len(targets) == 0 {
+		return fmt.Errorf("none of the changed files or functions were found in the cross reference")
+	}
+
+	shown := make(map[string]interface{})
+	pages := make(map[string]interface{})
+	dynamic := make(map[int]interface{})
+
+	fmt.Fprintf(logWriter, "Callers of the changed functions and pages\n")
+
+	for _, target := range targets {
+		if target.page {
+			pages[target.label] = nil
+		}
+
+		showCallers(target, 0, shown, pages)
+		dynamicCallers(target, dynamic)
+	}
+
+	// The pages reaching the changes, each listed once
+	entryPages := make([]string, 0, len(pages))
+	for page := range pages {
+		entryPages = append(entryPages, page)
+	}
+
+	sort.Strings(entryPages)
+
+	fmt.Fprintf(logWriter, "Affected entry pages: %d\n", len(entryPages))
+	for _, page := range entryPages {
+		fmt.Fprintf(logWriter, "    %s\n", page)
+	}
+
+	if len(dynamic) > 0 {
+		fmt.Fprintf(logWriter, "There are %d calls only known at run time that may also reach the changed functions\n", len(dynamic))
+	}
+
+	return nil
+}
+
+// expandImpactNames Replace the @file names with the names listed in the files.  The lists may also be the output
+// of dumpcrc (File name: lines) or filecrc records (flags:name|...) where only the modified, new and moved files are used,
+// the #key=value header of the filecrc records is skipped
+func expandImpactNames() ([]string, error) {
+	names := make([]string, 0, len(impactNames))
+
+	for _, name := range impactNames {
+		if !strings.HasPrefix(name, "@") {
+			names = append(names, name)
+			continue
+		}
+
+		file, err := os.Open(name[1:])
+
+		if err != nil {
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(scanBuff, 5000000)
+
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+
+			switch {
+			case strings.HasPrefix(line, "File name: "):
+				names = append(names, strings.TrimPrefix(line, "File name: "))
+			case len(line) > 4 && line[3] == ':' && strings.Contains(line, utils.FieldSep):
+				// A filecrc record, with the status flags in front of the name and the | and line ends escaped as %XX
+				if strings.ContainsAny(line[:3], "MNR") {
+					recName, err := utils.UnescapeField(line[4:strings.Index(line, utils.FieldSep)])
+
+					if err != nil {
+						file.Close()
+						return nil, fmt.Errorf("the record '%s' of %s is invalid: %s", line, name[1:], err)
+					}
+
+					names = append(names, recName)
+				}
+			case utils.IsHeaderLine(line):
+				// The header of the filecrc records
+			case len(line) > 0 && !strings.HasPrefix(line, "Flags:") && !strings.HasPrefix(line, "Created:"):
+				names = append(names, line)
+			}
+		}
+
+		file.Close()
+
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+// impactTargets Get the functions and pages changed for a file or component.method name
+func impactTargets(name string) []impactNode {
+	targets := make([]impactNode, 0)
+	base := filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+
+	// A component.method name
+	if !regexpFName.MatchString(base) && !isBrowserFile(base) {
+		dotLoc := strings.LastIndex(name, ".")
+
+		if dotLoc <= 0 {
+			fmt.Fprintf(logWriter, "The name '%s' is neither a file nor a component.method\n", name)
+			return targets
+		}
+
+		compKey, err := resolveComponent(normalizeComponent(name[:dotLoc]), "")
+
+		if err != nil {
+			fmt.Fprintln(logWriter, err)
+			return targets
+		}
+
+		funcKey := strings.ToLower(name[dotLoc+1:])
+
+		if _, found := xref[compKey].funcs[funcKey]; !found {
+			fmt.Fprintf(logWriter, "The method %s was not found in component %s\n", name[dotLoc+1:], xref[compKey].name)
+			return targets
+		}
+
+		return append(targets, functionNode(compKey, funcKey))
+	}
+
+	// A file given by its full name or relative to the root
+	relName := cleanDirName(name)
+
+	for _, root := range roots {
+		dir := strings.ReplaceAll(root.dir, `\`, "/")
+
+		if len(name) > len(dir) && strings.EqualFold(strings.ReplaceAll(name[:len(dir)], `\`, "/"), dir) {
+			relName = relativeName(name)
+			break
+		} else if workspaceMode && strings.HasPrefix(name, root.name+":") {
+			// Already qualified with the application or library
+			relName = strings.ReplaceAll(name, `\`, "/")
+		}
+	}
+
+	// The functions in the file, and the page itself
+	compKey := strings.ToLower(removeSuffix(relName))
+
+	if component, found := xref[compKey]; found {
+		for funcKey := range component.funcs {
+			targets = append(targets, functionNode(compKey, funcKey))
+		}
+	}
+
+	if isPage(relName) {
+		targets = append(targets, impactNode{key: strings.ToLower(relName), label: relName, page: true, file: relName})
+	}
+
+	if len(targets) == 0 && verboseMode {
+		fmt.Fprintf(logWriter, "The file %s has no functions in the cross reference\n", relName)
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].label < targets[j].label })
+
+	return targets
+}
+
+// functionNode Build the node for a function
+func functionNode(compKey string, funcKey string) impactNode {
+	component := xref[compKey]
+	return impactNode{key: compKey + "." + funcKey, label: component.name + "." + component.funcs[funcKey].name,
+		compKey: compKey, funcKey: funcKey}
+}
+
+// isPage Check if a file is an entry page rather than a component
+func isPage(relName string) bool {
+	return strings.EqualFold(filepath.Ext(relName), ".cfm") || isBrowserFile(relName)
+}
+
+// showCallers Display the callers of a node and, recursively, their callers
+// node: The function or page
+// depth: Depth in the tree, 0 for the changed functions
+// shown: Nodes whose callers have already been displayed
+// pages: The entry pages reached
+func showCallers(node impactNode, depth int, shown map[string]interface{}, pages map[string]interface{}) {
+	text := fmt.Sprintf("%s%d %s", strings.Repeat("    ", depth), depth, node.label)
+
+	if len(node.lines) > 0 {
+		text += fmt.Sprintf(" (%s %v)", node.file, node.lines)
+	}
+
+	if _, found := shown[node.key]; found && !node.page {
+		fmt.Fprintf(logWriter, "%s, callers shown above\n", text)
+		return
+	}
+
+	fmt.Fprintf(logWriter, "%s\n", text)
+	shown[node.key] = nil
+
+	if node.page {
+		pages[node.label] = nil
+		return
+	}
+
+	// Code outside of a function in a component has no callers
+	if len(node.funcKey) == 0 {
+		return
+	}
+
+	// A framework calling the function makes it an entry point too
+	if provider := xref[node.compKey].funcs[node.funcKey].provider; len(provider) > 0 {
+		pages[node.label+" (called by "+provider+")"] = nil
+	}
+
+	for _, caller := range callersOf(node.compKey, node.funcKey) {
+		showCallers(caller, depth+1, shown, pages)
+	}
+}
+
+// callersOf Get the functions and pages calling a function, in name order
+func callersOf(compKey string, funcKey string) []impactNode {
+	byKey := make(map[string]impactNode)
+
+	for _, usage := range xref[compKey].funcs[funcKey].usedBy {
+		for _, line := range usage.useLines {
+			caller := enclosingNode(usage.cleanName, line)
+			previous, found := byKey[caller.key]
+
+			if found {
+				caller = previous
+			}
+
+			caller.lines = append(caller.lines, line)
+			byKey[caller.key] = caller
+		}
+	}
+
+	callers := make([]impactNode, 0, len(byKey))
+	for _, caller := range byKey {
+		sort.Ints(caller.lines)
+		callers = append(callers, caller)
+	}
+
+	sort.Slice(callers, func(i, j int) bool { return callers[i].label < callers[j].label })
+
+	return callers
+}
+
+// enclosingNode Get the function (or page) containing a line of a file
+func enclosingNode(relName string, line int) impactNode {
+	compKey := strings.ToLower(removeSuffix(relName))
+	result := impactNode{key: strings.ToLower(relName), label: relName, page: isPage(relName), file: relName}
+	start := 0
+
+	// The innermost function holding the line
+	for funcKey, function := range xref[compKey].funcs {
+		if !function.implicit && function.line <= line && line <= function.endLine && function.line > start {
+			node := functionNode(compKey, funcKey)
+			node.file = relName
+			result = node
+			start = function.line
+		}
+	}
+
+	return result
+}
+
+// dynamicCallers Find the calls only known at run time that could call a changed function
+// dynamic: Indexes in the dynamic list of the calls found
+func dynamicCallers(node impactNode, dynamic map[int]interface{}) {
+	if len(node.funcKey) == 0 {
+		return
+	}
+
+	funcName := xref[node.compKey].funcs[node.funcKey].name
+
+	for index, spec := range dynamicList {
+		if renameConflict(spec, node.compKey, funcName) {
+			dynamic[index] = nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"dacdb.com/GoCode/filecrc/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandImpactNames(t *testing.T) {
+	a := assert.New(t)
+	savedNames := impactNames
+	t.Cleanup(func() { impactNames = savedNames })
+
+	// A plain list and the output of dumpcrc, only the file names of dumpcrc are used
+	listName := filepath.Join(t.TempDir(), "changes.txt")
+	content := "/CFC/Members.getList\n" +
+		"\n" +
+		"File name: /web/dump.cfm\n" +
+		"Flags: '-M-', Size: 10, CRC: 1\n" +
+		"Created: 2021-01-02T03:04:05Z, Modified: 2021-01-02T03:04:07Z, Accessed: 2021-01-02T03:04:06Z\n" +
+		"  /web/index.cfm  \n"
+
+	if !a.NoError(os.WriteFile(listName, []byte(content), 0644)) {
+		return
+	}
+
+	impactNames = []string{"/CFC/Person.getName", "@" + listName}
+	names, err := expandImpactNames()
+
+	if a.NoError(err) {
+		a.Equal([]string{"/CFC/Person.getName", "/CFC/Members.getList", "/web/dump.cfm", "/web/index.cfm"}, names)
+	}
+
+	// A list that can't be read
+	impactNames = []string{"@" + filepath.Join(t.TempDir(), "missing.txt")}
+	_, err = expandImpactNames()
+	a.Error(err)
+}
+
+func TestExpandImpactRecords(t *testing.T) {
+	a := assert.New(t)
+	savedNames := impactNames
+	t.Cleanup(func() { impactNames = savedNames })
+
+	// A filecrc baseline, only the changed, new and moved files are used with their names unescaped
+	header := utils.BaselineHeader{}
+	header.SetHost("web1")
+	header.SetRootDirs([]string{"/web"})
+	header.SetScanTime(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	var content strings.Builder
+	content.WriteString(header.BuildHeader())
+
+	for _, record := range []struct {
+		name string
+		flag func(info *utils.FileInfo)
+	}{
+		{"/web/a|b.cfm", func(info *utils.FileInfo) { info.SetMismatched(); info.SetSuspicious() }},
+		{"/web/100%.cfm", func(info *utils.FileInfo) { info.SetAdded() }},
+		{"/web/new.cfm", func(info *utils.FileInfo) { info.SetMoved("/web/old|name.cfm") }},
+		{"/web/same.cfm", func(info *utils.FileInfo) {}},
+		{"/web/gone.cfm", func(info *utils.FileInfo) { info.SetDeleted() }},
+	} {
+		info := utils.FileInfo{}
+		info.SetName(record.name)
+		info.SetSize(10)
+		info.SetModified(time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC))
+		info.SetCRC(1)
+		record.flag(&info)
+		content.WriteString(info.BuildCRCLine())
+	}
+
+	listName := filepath.Join(t.TempDir(), "changes.crc")
+	if !a.NoError(os.WriteFile(listName, []byte(content.String()), 0644)) {
+		return
+	}
+
+	impactNames = []string{"@" + listName}
+	names, err := expandImpactNames()
+
+	if a.NoError(err) {
+		a.Equal([]string{"/web/a|b.cfm", "/web/100%.cfm", "/web/new.cfm"}, names)
+	}
+
+	// A record that isn't escaped properly is refused
+	a.NoError(os.WriteFile(listName, []byte("-M-:/web/bad%zz.cfm|size=1\n"), 0644))
+	_, err = expandImpactNames()
+	a.Error(err)
+}
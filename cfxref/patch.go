@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Number of unchanged lines shown around each change in the patch
+const patchContext = 3
+
+// A range of lines to change in a file (1 based and inclusive)
+type lineRange struct {
+	first   int      // First line to change
+	last    int      // Last line to change
+	name    string   // Name of the function being changed
+	replace []string // Replacement lines (none when the lines are removed)
+}
+
+// readLines Read a file into lines, keeping any carriage returns so the patch matches the file
+func readLines(fileName string) ([]string, error) {
+	if err := checkPatchable(fileName); err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.SplitAfter(string(content), "\n"), nil
+}
+
+// writePatch Write the unified diff hunks changing the line ranges of a file
+// patch: Patch being built
+// relName: File name relative to the web root
+// lines: Content of the file
+// ranges: Sorted, non-overlapping line ranges to change
+func writePatch(patch *strings.Builder, relName string, lines []string, ranges []lineRange) {
+	// A trailing newline leaves an empty last entry
+	lineCt := len(lines)
+	if lineCt > 0 && len(lines[lineCt-1]) == 0 {
+		lineCt--
+	}
+
+	fmt.Fprintf(patch, "--- a%s\n+++ b%s\n", relName, relName)
+
+	offset := 0
+	for index := 0; index < len(ranges); {
+		// Combine the ranges whose context overlaps into one hunk
+		last := index
+		for last+1 < len(ranges) && ranges[last+1].first-ranges[last].last <= 2*patchContext+1 {
+			last++
+		}
+
+		start := ranges[index].first - patchContext
+		if start < 1 {
+			start = 1
+		}
+
+		end := ranges[last].last + patchContext
+		if end > lineCt {
+			end = lineCt
+		}
+
+		// Net change in the number of lines for the hunk
+		change := 0
+		for _, span := range ranges[index : last+1] {
+			change += len(span.replace) - (span.last - span.first + 1)
+		}
+
+		// An empty result is numbered from the line before it
+		oldCt := end - start + 1
+		newStart := start + offset
+		if oldCt+change == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(patch, "@@ -%d,%d +%d,%d @@\n", start, oldCt, newStart, oldCt+change)
+
+		current := index
+		for lineNo := start; lineNo <= end; lineNo++ {
+			if current <= last && lineNo == ranges[current].first {
+				// Remove the old lines and add their replacement
+				for ; lineNo <= ranges[current].last; lineNo++ {
+					writePatchLine(patch, "-", lines[lineNo-1])
+				}
+
+				for _, text := range ranges[current].replace {
+					writePatchLine(patch, "+", text)
+				}
+
+				lineNo--
+				current++
+				continue
+			}
+
+			writePatchLine(patch, " ", lines[lineNo-1])
+		}
+
+		offset += change
+		index = last + 1
+	}
+}
+
+// writePatchLine Write a single line of a hunk, flagging a line without a newline
+func writePatchLine(patch *strings.Builder, prefix string, text string) {
+	patch.WriteString(prefix + text)
+
+	if !strings.HasSuffix(text, "\n") {
+		patch.WriteString("\n\\ No newline at end of file\n")
+	}
+}
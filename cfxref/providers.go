@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// A source of calls that don't appear in the code, such as a framework calling handler methods by convention
+type usageProvider interface {
+	// label Name shown for the calls made by the provider
+	label() string
+	// calledMethods Get the keys of the functions of a component that are called
+	// relName: Relative name of the file defining the component
+	calledMethods(relName string, component compDef) []string
+}
+
+// Framework calling the public methods of the components in directories with a conventional name,
+// i.e. the actions of the ColdBox handlers or the FW/1 controllers
+type conventionProvider struct {
+	name     string   // Name of the framework
+	dirNames []string // Lower case names of the directories holding the components called
+}
+
+// Provider configured with regular expressions for the components and the methods called
+type patternProvider struct {
+	name        string         // Name shown for the calls
+	application string         // Application of a workspace holding the components, any application when empty
+	component   *regexp.Regexp // Relative file names of the components called
+	method      *regexp.Regexp // Names of the methods called
+}
+
+// The usage providers configured
+var usageProviders = make([]usageProvider, 0, 5)
+
+// Number of functions called by a usage provider
+var providerCallCt int = 0
+
+// parseProviders Get the usage providers from the configuration
+// returns false if the configuration is invalid
+func parseProviders(val interface{}) bool {
+	passed := true
+	specs := val.(map[string]interface{})
+
+	for option, setting := range specs {
+		switch option {
+		case "coldbox":
+			passed = addConvention("ColdBox", []string{"handlers"}, setting) && passed
+		case "fw1":
+			passed = addConvention("FW/1", []string{"controllers"}, setting) && passed
+		case "patterns":
+			for _, entry := range setting.([]interface{}) {
+				provider, err := parsePattern(entry.(map[string]interface{}))
+
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid %s entry in %s: %s\n", option, KwFrameworks, err)
+					passed = false
+					continue
+				}
+
+				usageProviders = append(usageProviders, provider)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Invalid %s parameter '%s'\n", KwFrameworks, option)
+			passed = false
+		}
+	}
+
+	return passed
+}
+
+// addConvention Add a framework provider, which is either turned on with the default directory names or given the names
+// setting: true, false or an array of directory names
+func addConvention(name string, dirNames []string, setting interface{}) bool {
+	switch value := setting.(type) {
+	case bool:
+		if !value {
+			return true
+		}
+	case []interface{}:
+		dirNames = make([]string, 0, len(value))
+		for _, dirName := range value {
+			dirNames = append(dirNames, strings.ToLower(strings.Trim(dirName.(string), `/\`)))
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "The %s setting for %s must be true, false or an array of directory names\n", KwFrameworks, name)
+		return false
+	}
+
+	usageProviders = append(usageProviders, conventionProvider{name: name, dirNames: dirNames})
+
+	return true
+}
+
+// parsePattern Get a provider of the methods matching regular expressions
+func parsePattern(specs map[string]interface{}) (usageProvider, error) {
+	provider := patternProvider{}
+	var err error
+
+	for key, val := range specs {
+		switch strings.ToLower(key) {
+		case "name":
+			provider.name = val.(string)
+		case "application":
+			provider.application = val.(string)
+		case "component":
+			provider.component, err = regexp.Compile(`(?i)` + val.(string))
+		case "method":
+			provider.method, err = regexp.Compile(`(?i)` + val.(string))
+		default:
+			err = fmt.Errorf("unknown keyword '%s'", key)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(provider.name) == 0 || provider.component == nil || provider.method == nil {
+		return nil, fmt.Errorf("a name, a component and a method pattern are required")
+	}
+
+	return provider, nil
+}
+
+// label Name shown for the calls made by the framework
+func (provider conventionProvider) label() string {
+	return provider.name
+}
+
+// calledMethods Get the public methods of a component in one of the framework's directories
+func (provider conventionProvider) calledMethods(relName string, component compDef) []string {
+	called := make([]string, 0)
+	dirs := strings.Split(strings.ToLower(relName[len(qualifier(relName)):]), "/")
+
+	for _, dir := range dirs[:len(dirs)-1] {
+		for _, dirName := range provider.dirNames {
+			if dir != dirName {
+				continue
+			}
+
+			// Private and package methods can't be reached through the routing
+			for funcKey, function := range component.funcs {
+				if access := strings.ToLower(function.attrs["access"]); access != "private" && access != "package" {
+					called = append(called, funcKey)
+				}
+			}
+
+			return called
+		}
+	}
+
+	return called
+}
+
+// label Name shown for the calls matching the patterns
+func (provider patternProvider) label() string {
+	return provider.name
+}
+
+// calledMethods Get the methods matching the pattern when the component matches
+func (provider patternProvider) calledMethods(relName string, component compDef) []string {
+	called := make([]string, 0)
+
+	prefix := qualifier(relName)
+
+	if len(provider.application) > 0 && !strings.EqualFold(prefix, provider.application+":") {
+		return called
+	}
+
+	// The pattern is for the name in the root, without the application of a workspace
+	if !provider.component.MatchString(relName[len(prefix):]) {
+		return called
+	}
+
+	for funcKey, function := range component.funcs {
+		if provider.method.MatchString(function.name) {
+			called = append(called, funcKey)
+		}
+	}
+
+	return called
+}
+
+// processProviders Mark the functions called by the usage providers, the first provider calling a function is kept
+func processProviders() {
+	providerCallCt = 0
+
+	for _, component := range xref {
+		relName := relativeName(component.fileName)
+
+		for _, provider := range usageProviders {
+			for _, funcKey := range provider.calledMethods(relName, component) {
+				if function := component.funcs[funcKey]; len(function.provider) == 0 {
+					function.provider = provider.label()
+					component.funcs[funcKey] = function
+				}
+			}
+		}
+
+		// The functions marked before files changed when watching are counted too
+		for _, function := range component.funcs {
+			if len(function.provider) > 0 {
+				providerCallCt++
+			}
+		}
+	}
+}
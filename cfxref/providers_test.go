@@ -0,0 +1,101 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// handlerComponent Build a component with methods of each access level
+func handlerComponent(fileName string) compDef {
+	return compDef{name: removeSuffix(relativeName(fileName)), fileName: fileName, funcs: map[string]funcDef{
+		"index":  {name: "index", attrs: map[string]string{"access": "public"}},
+		"list":   {name: "list", attrs: map[string]string{}},
+		"remote": {name: "remote", attrs: map[string]string{"access": "Remote"}},
+		"secret": {name: "secret", attrs: map[string]string{"access": "Private"}},
+		"helper": {name: "helper", attrs: map[string]string{"access": "package"}},
+	}}
+}
+
+func TestConventionProvider(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+
+	coldBox := conventionProvider{name: "ColdBox", dirNames: []string{"handlers"}}
+	fw1 := conventionProvider{name: "FW/1", dirNames: []string{"controllers"}}
+
+	// The public methods of the handlers and controllers are called, not the private and package ones
+	a.ElementsMatch([]string{"index", "list", "remote"}, coldBox.calledMethods("/Handlers/Main.cfc", handlerComponent("/web/Handlers/Main.cfc")))
+	a.ElementsMatch([]string{"index", "list", "remote"}, fw1.calledMethods("/admin/controllers/Main.cfc", handlerComponent("/web/admin/controllers/Main.cfc")))
+
+	// Components elsewhere, or only named like the directory, aren't called
+	a.Empty(coldBox.calledMethods("/models/Main.cfc", handlerComponent("/web/models/Main.cfc")))
+	a.Empty(coldBox.calledMethods("/handlers.cfc", handlerComponent("/web/handlers.cfc")))
+	a.Empty(fw1.calledMethods("/handlers/Main.cfc", handlerComponent("/web/handlers/Main.cfc")))
+}
+
+func TestProcessProviders(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	useXref(t)
+
+	savedProviders, savedCt := usageProviders, providerCallCt
+	defer func() { usageProviders, providerCallCt = savedProviders, savedCt }()
+	usageProviders = []usageProvider{conventionProvider{name: "ColdBox", dirNames: []string{"handlers"}}}
+
+	xref["/handlers/main"] = handlerComponent("/web/handlers/Main.cfc")
+	xref["/models/main"] = handlerComponent("/web/models/Main.cfc")
+
+	processProviders()
+
+	handler := xref["/handlers/main"].funcs
+	a.Equal("ColdBox", handler["index"].provider)
+	a.Equal("ColdBox", handler["list"].provider)
+	a.Empty(handler["secret"].provider)
+	a.Empty(handler["helper"].provider)
+	a.Empty(xref["/models/main"].funcs["index"].provider)
+	a.Equal(3, providerCallCt)
+
+	// Marking the functions again counts them once
+	processProviders()
+	a.Equal(3, providerCallCt)
+}
+
+func TestPatternProvider(t *testing.T) {
+	a := assert.New(t)
+	useWorkspace(t, &rootDef{name: "app", dir: "/web/app", mappings: map[string]string{}},
+		&rootDef{name: "admin", dir: "/web/admin", mappings: map[string]string{}})
+
+	provider := patternProvider{name: "Framework", component: regexp.MustCompile(`^/handlers/`),
+		method: regexp.MustCompile(`^on`)}
+	component := compDef{funcs: map[string]funcDef{"onstart": {name: "onStart"}, "helper": {name: "helper"}}}
+
+	// The pattern is matched without the application name
+	a.Equal([]string{"onstart"}, provider.calledMethods("app:/handlers/Main.cfc", component))
+	a.Equal([]string{"onstart"}, provider.calledMethods("admin:/handlers/Main.cfc", component))
+	a.Empty(provider.calledMethods("app:/models/Main.cfc", component))
+
+	// Only the configured application is matched
+	provider.application = "App"
+	a.Equal([]string{"onstart"}, provider.calledMethods("app:/handlers/Main.cfc", component))
+	a.Empty(provider.calledMethods("admin:/handlers/Main.cfc", component))
+}
+
+func TestParsePattern(t *testing.T) {
+	a := assert.New(t)
+
+	provider, err := parsePattern(map[string]interface{}{"name": "Taffy", "application": "api",
+		"component": "^/resources/", "method": "^(get|post)$"})
+
+	if a.NoError(err) {
+		a.Equal("api", provider.(patternProvider).application)
+		a.True(provider.(patternProvider).method.MatchString("GET"))
+	}
+
+	_, err = parsePattern(map[string]interface{}{"name": "Taffy", "component": "^/resources/"})
+	a.Error(err)
+
+	_, err = parsePattern(map[string]interface{}{"name": "Taffy", "component": "(", "method": "x"})
+	a.Error(err)
+}
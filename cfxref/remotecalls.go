@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Regular expression for the base part of the JavaScript and HTML file names scanned for remote calls
+var regexpBrowserFName = regexp.MustCompile(`(?i)^[a-zA-Z].*\.(js|html|htm)$`)
+
+// Regular expression for a URL to a component, i.e. /cfc/Members.cfc?method=getList.  The groups are
+// 1: the component path (with any scheme and host), 2: the query string
+var regexpCFCURL = regexp.MustCompile(`(?i)((?:https?:)?(?://[^/"'\s]+)?[\w./~-]*[\w-])\.cfc\b(?:\?([^"'\s<>)]*))?`)
+
+// Regular expression for the method parameter in a query string
+var regexpURLMethod = regexp.MustCompile(`(?i)(?:^|&)method=([^&#]*)`)
+
+// Regular expression for the method given in the AJAX data rather than the URL, i.e.
+// $.ajax({url: "/cfc/Members.cfc", method: "POST", data: {method: "getList"}}), where the first method is the HTTP verb
+var regexpAjaxMethod = regexp.MustCompile(`(?i)^["']?method["']?\s*:\s*["'](\w+)["']`)
+
+// Regular expression for the start of the AJAX data object, i.e. data: {
+var regexpAjaxData = regexp.MustCompile(`(?i)^["']?data["']?\s*:\s*\{`)
+
+// Regular expression for the AJAX data given as a query string, i.e. data: "method=getList&id=1"
+var regexpAjaxQuery = regexp.MustCompile(`(?i)^["']?data["']?\s*:\s*["']([^"']*)`)
+
+// Regular expression for the data object passed right after the URL, i.e. $.post("/cfc/Members.cfc", {method: "getList"})
+var regexpDataArg = regexp.MustCompile(`^["']?\s*,\s*\{`)
+
+// Number of lines after a URL without a method to look for the method in the AJAX data
+const ajaxMethodLines = 10
+
+// Remote call tracking for the file currently being scanned
+type remoteState struct {
+	component string // Component of a URL without a method
+	line      int    // Line of the URL without a method
+	dataDepth int    // Brace depth in the AJAX data object (0 when not in it)
+}
+
+// JavaScript proxy class created by a cfajaxproxy tag
+type ajaxProxy struct {
+	component string // Component the proxy calls
+	fileName  string // File with the cfajaxproxy tag
+}
+
+// Components of the cfajaxproxy JavaScript classes by lower case class name
+var ajaxProxies = make(map[string]ajaxProxy, 100)
+
+// isBrowserFile Check if a file is JavaScript or HTML, which is only scanned for remote calls
+func isBrowserFile(fileName string) bool {
+	return regexpBrowserFName.MatchString(filepath.Base(fileName))
+}
+
+// parseBrowserFile Find the remote calls in a JavaScript or HTML file
+func parseBrowserFile(fileName string) error {
+	content, err := readSource(fileName)
+	if err != nil {
+		return err
+	}
+
+	lineNo := 0
+	remote := remoteState{}
+	comments := newCommentState(fileName)
+
+	// JavaScript files are all script
+	if strings.EqualFold(filepath.Ext(fileName), ".js") {
+		comments.scriptFile = true
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(scanBuff, 5000000)
+
+	for scanner.Scan() {
+		lineNo++
+		code, _ := comments.strip(scanner.Text())
+		scanRemoteCalls(&remote, fileName, lineNo, code)
+		scanProxyCalls(fileName, lineNo, code)
+	}
+
+	return scanner.Err()
+}
+
+// scanRemoteCalls Find the URL and AJAX calls to components and the cfajaxproxy tags in a line
+// remote: Remote call tracking for the file
+// fileName: Full name of the file being scanned
+// lineNo: Line number in the file
+// code: Text of the line with the comments removed
+func scanRemoteCalls(remote *remoteState, fileName string, lineNo int, code string) {
+	relName := relativeName(fileName)
+	methodFrom := 0
+
+	for _, match := range regexpCFCURL.FindAllStringSubmatchIndex(code, -1) {
+		component := urlComponent(relName, subMatch(code, match, 1))
+		call := defInvoke{fileName: relName, line: lineNo, kind: callRemote, component: component}
+		query := subMatch(code, match, 2)
+		methodFrom = match[1]
+
+		if method := regexpURLMethod.FindStringSubmatchIndex(query); method != nil {
+			call.method = query[method[2]:method[3]]
+			call.column = match[4] + method[2]
+		} else {
+			// The method may be passed in the AJAX data
+			remote.component = component
+			remote.line = lineNo
+			continue
+		}
+
+		if strings.Contains(call.method, "#") || len(call.method) == 0 || strings.Contains(call.method, "+") {
+			call.dynamic = true
+			dynamicList = append(dynamicList, call)
+			continue
+		}
+
+		deferredList = append(deferredList, call)
+	}
+
+	// The method for a URL given without one
+	if len(remote.component) > 0 {
+		if method, column := remote.dataMethod(code[methodFrom:], remote.line == lineNo); column >= 0 {
+			deferredList = append(deferredList, defInvoke{fileName: relName, line: lineNo, kind: callRemote,
+				component: remote.component, method: method, column: methodFrom + column})
+			remote.component = ""
+		} else if lineNo-remote.line >= ajaxMethodLines || len(remote.component) == 0 {
+			remote.component = ""
+			remote.dataDepth = 0
+		}
+	}
+
+	// Remember the JavaScript classes created for components
+	if start := indexTag(strings.ToLower(code), "<cfajaxproxy"); start >= 0 {
+		attrs := parseAttributes(tagText(code, start))
+
+		if len(attrs["cfc"]) > 0 {
+			className := attrs["jsclassname"]
+
+			// The class name defaults to the name of the component
+			if len(className) == 0 {
+				className = attrs["cfc"][strings.LastIndex(attrs["cfc"], ".")+1:]
+			}
+
+			ajaxProxies[strings.ToLower(className)] = ajaxProxy{component: normalizeComponent(attrs["cfc"]), fileName: relName}
+		}
+	}
+}
+
+// dataMethod Find the method of a remote call in the AJAX data, which may span lines.  Only the method in
+// the data is taken, not the method (or type) giving the HTTP verb
+// text: Code following the URL of the call
+// afterURL: The text follows the URL on the same line, so it may be the data argument of $.get or $.post
+// returns the method and its position in the text, -1 when not found.  The component is cleared
+// when the data ends without a method
+func (remote *remoteState) dataMethod(text string, afterURL bool) (string, int) {
+	start := 0
+
+	// Past the quote closing the URL
+	if afterURL && len(text) > 0 && (text[0] == '"' || text[0] == '\'') {
+		start = 1
+	}
+
+	if match := regexpDataArg.FindStringIndex(text); afterURL && match != nil {
+		remote.dataDepth = 1
+		start = match[1]
+	}
+
+	quote := byte(0)
+
+	for index := start; index < len(text); index++ {
+		rest := text[index:]
+		keyStart := index == 0 || !isWordChar(text[index-1])
+
+		switch {
+		case quote != 0:
+			if text[index] == quote {
+				quote = 0
+			}
+		case remote.dataDepth == 1 && keyStart && regexpAjaxMethod.MatchString(rest):
+			match := regexpAjaxMethod.FindStringSubmatchIndex(rest)
+			remote.dataDepth = 0
+			return subMatch(rest, match, 1), index + match[2]
+		case remote.dataDepth == 0 && keyStart && regexpAjaxQuery.MatchString(rest):
+			match := regexpAjaxQuery.FindStringSubmatchIndex(rest)
+			query := subMatch(rest, match, 1)
+
+			if method := regexpURLMethod.FindStringSubmatchIndex(query); method != nil {
+				return query[method[2]:method[3]], index + match[2] + method[2]
+			}
+
+			// Past the closing quote
+			index += match[1]
+		case remote.dataDepth == 0 && keyStart && regexpAjaxData.MatchString(rest):
+			remote.dataDepth = 1
+			index += len(regexpAjaxData.FindString(rest)) - 1
+		case text[index] == '"' || text[index] == '\'':
+			quote = text[index]
+		case text[index] == '{' && remote.dataDepth > 0:
+			remote.dataDepth++
+		case text[index] == '}' && remote.dataDepth > 0:
+			remote.dataDepth--
+
+			// The data doesn't give the method
+			if remote.dataDepth == 0 {
+				remote.component = ""
+				return "", -1
+			}
+		}
+	}
+
+	return "", -1
+}
+
+// isWordChar Check if a character may be part of a name
+func isWordChar(char byte) bool {
+	return char == '_' || char == '$' || (char >= '0' && char <= '9') || (char|0x20 >= 'a' && char|0x20 <= 'z')
+}
+
+// scanProxyCalls Find the objects created and the methods called in JavaScript, which only count when they
+// turn out to be cfajaxproxy objects
+func scanProxyCalls(fileName string, lineNo int, code string) {
+	relName := relativeName(fileName)
+
+	for _, match := range regexpCreate.FindAllStringSubmatch(code, -1) {
+		if len(match[4]) > 0 {
+			objectTypes[objectKey(relName, match[1])] = match[4]
+		}
+	}
+
+	for _, match := range regexpMemberCall.FindAllStringSubmatchIndex(code, -1) {
+		pendingCalls = append(pendingCalls, defInvoke{fileName: relName, line: lineNo, kind: callMember,
+			component: subMatch(code, match, 1), method: subMatch(code, match, 2), column: match[4], remote: true})
+	}
+}
+
+// urlComponent Get the component from the path of a URL, which may be relative to the file it's in
+func urlComponent(relName string, url string) string {
+	// Remove any scheme and host
+	if strings.HasPrefix(url, "//") || strings.Contains(url, "://") {
+		url = url[strings.Index(url, "//")+2:]
+		url = url[strings.IndexByte(url+"/", '/'):]
+	}
+
+	if !strings.HasPrefix(url, "/") {
+		url = path.Join(path.Dir(relName), url)
+	}
+
+	return path.Clean(url)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// remoteCalls Scan the lines of a page for remote calls
+// returns the calls found
+func remoteCalls(t *testing.T, lines ...string) []defInvoke {
+	useRoot(t, "/web")
+	savedDeferred, savedDynamic := deferredList, dynamicList
+	t.Cleanup(func() {
+		deferredList, dynamicList = savedDeferred, savedDynamic
+	})
+
+	deferredList = nil
+	dynamicList = nil
+	remote := remoteState{}
+
+	for index, line := range lines {
+		scanRemoteCalls(&remote, "/web/js/members.js", index+1, line)
+	}
+
+	return deferredList
+}
+
+func TestAjaxHTTPMethod(t *testing.T) {
+	a := assert.New(t)
+	code := `$.ajax({url:"/CFC/Members.cfc", method:"POST", data:{method:"getList"}})`
+	calls := remoteCalls(t, code)
+
+	if a.Len(calls, 1) {
+		a.Equal("/CFC/Members", calls[0].component)
+		a.Equal("getList", calls[0].method)
+		a.Equal(strings.Index(code, "getList"), calls[0].column)
+	}
+}
+
+func TestAjaxMethodForms(t *testing.T) {
+	a := assert.New(t)
+
+	// In the query string
+	calls := remoteCalls(t, `fetch("/CFC/Members.cfc?method=getList&id=1")`)
+	if a.Len(calls, 1) {
+		a.Equal("getList", calls[0].method)
+	}
+
+	// In the data over several lines, after the HTTP verb
+	calls = remoteCalls(t,
+		`$.ajax({`,
+		`    url: "/CFC/Members.cfc",`,
+		`    type: "GET",`,
+		`    "data": {`,
+		`        id: 1, options: {method: "nested"},`,
+		`        "method": "getMember"`,
+		`    }`,
+		`});`)
+	if a.Len(calls, 1) {
+		a.Equal("getMember", calls[0].method)
+		a.Equal(6, calls[0].line)
+	}
+
+	// The data passed after the URL
+	calls = remoteCalls(t, `$.post("/CFC/Members.cfc", {method: "save", name: n});`)
+	if a.Len(calls, 1) {
+		a.Equal("save", calls[0].method)
+	}
+
+	// The data as a query string
+	calls = remoteCalls(t, `$.ajax({url: "/CFC/Members.cfc", method: "POST", data: "id=1&method=remove"});`)
+	if a.Len(calls, 1) {
+		a.Equal("remove", calls[0].method)
+	}
+
+	// Data without a method
+	a.Empty(remoteCalls(t, `$.ajax({url: "/CFC/Members.cfc", method: "POST", data: {id: 1}});`, `x = {method: "later"};`))
+	a.Empty(remoteCalls(t, `$.ajax({url: "/CFC/Members.cfc", method: "POST"});`))
+}
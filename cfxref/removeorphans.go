@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Regular expression for the start of a tag function definition
+var regexpFuncStart = regexp.MustCompile(`(?i)<cffunction\s`)
+
+// Regular expression for the end of a tag function definition
+var regexpFuncEnd = regexp.MustCompile(`(?i)</cffunction\s*>`)
+
+// Text of a function to remove, which may share its first and last lines with other code
+type removalSpan struct {
+	first    int    // Line the function starts on (1 based)
+	firstCol int    // Position the function starts at in its first line
+	last     int    // Line the function ends on
+	lastCol  int    // Position after the end of the function in its last line
+	name     string // Name of the function
+}
+
+// Orphan removal settings
+var removeOrphans bool = false  // Generate a patch to remove orphaned functions
+var removePatchName string = "" // Name of the patch file to write
+var removeListName string = ""  // Optional orphan list limiting what is removed
+var removeDryRun bool = false   // Only summarize the removal
+
+// generateRemoval Build a unified diff that removes the orphaned functions from their files
+func generateRemoval() error {
+	// Get the list of orphans to remove
+	selected, err := loadOrphanList(removeListName)
+
+	if err != nil {
+		return err
+	}
+
+	// Collect the functions to remove for each file
+	removals := make(map[string][]lineRange)
+
+	for _, component := range xref {
+		orphanList, found := orphans[component.name]
+
+		if !found {
+			continue
+		}
+
+		for _, functionName := range orphanList {
+			key := strings.ToLower(component.name + "." + functionName)
+
+			if selected != nil {
+				if _, found := selected[key]; !found {
+					continue
+				}
+
+				delete(selected, key)
+			}
+
+			function := component.funcs[strings.ToLower(functionName)]
+			removals[component.fileName] = append(removals[component.fileName],
+				lineRange{first: function.line, last: function.endLine, name: function.name})
+		}
+	}
+
+	// Anything left in the selection is no longer an orphan
+	for key := range selected {
+		fmt.Fprintf(logWriter, "The function %s is not an orphan and will not be removed\n", key)
+	}
+
+	// Process the files in order so the patch is repeatable
+	fileNames := make([]string, 0, len(removals))
+	for fileName := range removals {
+		fileNames = append(fileNames, fileName)
+	}
+
+	sort.Strings(fileNames)
+
+	var patch strings.Builder
+	totalLines := 0
+
+	fmt.Fprintf(logWriter, "Orphan removal summary\n")
+
+	for _, fileName := range fileNames {
+		lines, err := readLines(fileName)
+
+		if err != nil {
+			return err
+		}
+
+		// Find the text of each function, which can't be removed if it isn't where it was found in the scan
+		spans := make([]removalSpan, 0, len(removals[fileName]))
+
+		for _, function := range removals[fileName] {
+			span, found := locateFunction(lines, function)
+
+			if !found {
+				fmt.Fprintf(logWriter, "    The function %s in %s could not be located and was not removed\n",
+					function.name, relativeName(fileName))
+				continue
+			}
+
+			spans = append(spans, span)
+		}
+
+		if len(spans) == 0 {
+			continue
+		}
+
+		ranges := removalRanges(lines, spans)
+
+		removed := 0
+		for _, span := range ranges {
+			removed += span.last - span.first + 1 - len(span.replace)
+		}
+
+		totalLines += removed
+		fmt.Fprintf(logWriter, "    %s: %d lines removed in %d functions\n", relativeName(fileName), removed, len(spans))
+
+		writePatch(&patch, patchName(relativeName(fileName)), lines, ranges)
+	}
+
+	fmt.Fprintf(logWriter, "Total lines removed: %d\n", totalLines)
+
+	if removeDryRun {
+		fmt.Fprintf(logWriter, "Dry run specified, no patch was written\n")
+		return nil
+	}
+
+	return os.WriteFile(removePatchName, []byte(patch.String()), 0644)
+}
+
+// loadOrphanList Read an orphan list (in the orphan report format) of the functions to remove
+// returns a set of component.function names or nil when all orphans are to be removed
+func loadOrphanList(fileName string) (map[string]interface{}, error) {
+	if len(fileName) == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Open(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	selected := make(map[string]interface{})
+	component := ""
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "Component:") {
+			component = strings.TrimSpace(strings.TrimPrefix(line, "Component:"))
+		} else if len(line) > 0 && len(component) > 0 && strings.HasPrefix(scanner.Text(), " ") {
+			// The function name may be followed by an annotation
+			selected[strings.ToLower(component+"."+strings.Fields(line)[0])] = nil
+		}
+	}
+
+	return selected, scanner.Err()
+}
+
+// locateFunction Find where a function starts and ends in its first and last lines
+// function: Lines of the function found by the scan
+// returns the text of the function and whether it was found
+func locateFunction(lines []string, function lineRange) (removalSpan, bool) {
+	span := removalSpan{first: function.first, last: function.last, name: function.name}
+
+	if function.first < 1 || function.last > len(lines) || function.last < function.first {
+		return span, false
+	}
+
+	first := lines[function.first-1]
+
+	// A tag function runs through its closing tag
+	if start := regexpFuncStart.FindStringIndex(first); start != nil {
+		span.firstCol = start[0]
+		from := 0
+
+		if span.last == span.first {
+			from = span.firstCol
+		}
+
+		end := regexpFuncEnd.FindStringIndex(lines[span.last-1][from:])
+
+		if end == nil {
+			return span, false
+		}
+
+		span.lastCol = from + end[1]
+		return span, true
+	}
+
+	// A script function runs through the brace closing its body
+	for _, found := range regexpScriptFunc.FindAllStringSubmatchIndex(first, -1) {
+		if !strings.EqualFold(first[found[2]:found[3]], function.name) {
+			continue
+		}
+
+		span.firstCol = scriptFuncStart(first, found)
+		depth := 0
+		quote := byte(0)
+
+		for lineNo := span.first; lineNo <= len(lines); lineNo++ {
+			line := lines[lineNo-1]
+			index := 0
+
+			if lineNo == span.first {
+				index = found[1]
+			}
+
+			for ; index < len(line); index++ {
+				switch {
+				case quote != 0:
+					if line[index] == quote {
+						quote = 0
+					}
+				case line[index] == '"' || line[index] == '\'':
+					quote = line[index]
+				case line[index] == '{':
+					depth++
+				case line[index] == '}':
+					depth--
+
+					if depth == 0 {
+						span.last = lineNo
+						span.lastCol = index + 1
+						return span, true
+					}
+				}
+			}
+		}
+
+		return span, false
+	}
+
+	return span, false
+}
+
+// removalRanges Get the line ranges to change in a file to remove functions, with the comment blocks before
+// the functions starting their lines, keeping any other code on the first and last lines
+// spans: Functions to remove
+// returns the sorted, non-overlapping line ranges with the code to keep as their replacement
+func removalRanges(lines []string, spans []removalSpan) []lineRange {
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].first < spans[j].first || (spans[i].first == spans[j].first && spans[i].firstCol < spans[j].firstCol)
+	})
+
+	// Take the whole lines when there is nothing else on them
+	for index := range spans {
+		span := &spans[index]
+
+		if len(strings.TrimSpace(lines[span.first-1][:span.firstCol])) == 0 {
+			span.firstCol = 0
+			span.first = extendRemoval(lines, lineRange{first: span.first, last: span.last}).first
+		}
+
+		if last := lines[span.last-1]; len(strings.TrimSpace(last[span.lastCol:])) == 0 {
+			span.lastCol = len(last)
+		}
+	}
+
+	ranges := make([]lineRange, 0, len(spans))
+
+	for index := 0; index < len(spans); {
+		// Functions sharing a line are removed together
+		group := spans[index : index+1]
+		for index+len(group) < len(spans) && spans[index+len(group)].first == group[len(group)-1].last {
+			group = spans[index : index+len(group)+1]
+		}
+
+		index += len(group)
+
+		// The code left around the functions
+		kept := lines[group[0].first-1][:group[0].firstCol]
+
+		for at := 1; at < len(group); at++ {
+			kept += lines[group[at].first-1][group[at-1].lastCol:group[at].firstCol]
+		}
+
+		end := group[len(group)-1]
+		last := lines[end.last-1]
+		kept += last[end.lastCol:]
+
+		// Keep the line ending when the end of the last line was removed
+		if !strings.HasSuffix(kept, "\n") {
+			kept += last[len(strings.TrimRight(last, "\r\n")):]
+		}
+
+		span := lineRange{first: group[0].first, last: end.last, name: group[0].name}
+
+		if len(strings.TrimSpace(kept)) > 0 {
+			for _, line := range strings.SplitAfter(kept, "\n") {
+				if len(line) > 0 {
+					span.replace = append(span.replace, trimLineEnd(line))
+				}
+			}
+		} else if span.first > 1 && span.last < len(lines) && isBlank(lines[span.first-2]) && isBlank(lines[span.last]) {
+			// Don't leave two blank lines where the function was
+			span.last++
+		}
+
+		ranges = append(ranges, span)
+	}
+
+	return ranges
+}
+
+// isBlank Check if a line holds only white space, but isn't the empty remainder after the last newline
+func isBlank(line string) bool {
+	return len(line) > 0 && len(strings.TrimSpace(line)) == 0
+}
+
+// trimLineEnd Remove the white space left at the end of a line in front of its line ending
+func trimLineEnd(line string) string {
+	text := strings.TrimRight(line, "\r\n")
+	return strings.TrimRight(text, " \t") + line[len(text):]
+}
+
+// extendRemoval Extend the lines to remove to include the preceding comment block
+func extendRemoval(lines []string, function lineRange) lineRange {
+	for function.first > 1 {
+		previous := strings.TrimSpace(lines[function.first-2])
+		start := function.first - 1
+
+		switch {
+		case strings.HasPrefix(previous, "//"):
+			// Single line script comment
+		case strings.HasSuffix(previous, "--->"):
+			// CFML comment, find where it starts
+			for start > 1 && !strings.Contains(lines[start-1], "<!---") {
+				start--
+			}
+
+			if !strings.HasPrefix(strings.TrimSpace(lines[start-1]), "<!---") {
+				return function
+			}
+		case strings.HasSuffix(previous, "*/"):
+			// Script block comment, find where it starts
+			for start > 1 && !strings.Contains(lines[start-1], "/*") {
+				start--
+			}
+
+			if !strings.HasPrefix(strings.TrimSpace(lines[start-1]), "/*") {
+				return function
+			}
+		default:
+			// Not a comment so done
+			return function
+		}
+
+		function.first = start
+	}
+
+	return function
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// removalPatch Build the patch removing functions from the text of a file
+func removalPatch(t *testing.T, text string, functions ...lineRange) string {
+	lines := strings.SplitAfter(text, "\n")
+	spans := make([]removalSpan, 0, len(functions))
+
+	for _, function := range functions {
+		span, found := locateFunction(lines, function)
+		assert.True(t, found, function.name)
+		spans = append(spans, span)
+	}
+
+	var patch strings.Builder
+	writePatch(&patch, "/x.cfc", lines, removalRanges(lines, spans))
+
+	return patch.String()
+}
+
+func TestRemoveTagFunction(t *testing.T) {
+	text := "<cfcomponent>\n" +
+		"\n" +
+		"<!--- Old --->\n" +
+		"<cffunction name=\"old\">\n" +
+		"</cffunction>\n" +
+		"\n" +
+		"<cffunction name=\"keep\">\n" +
+		"</cffunction>\n" +
+		"</cfcomponent>\n"
+
+	assert.Equal(t, "--- a/x.cfc\n+++ b/x.cfc\n"+
+		"@@ -1,9 +1,5 @@\n"+
+		" <cfcomponent>\n"+
+		" \n"+
+		"-<!--- Old --->\n"+
+		"-<cffunction name=\"old\">\n"+
+		"-</cffunction>\n"+
+		"-\n"+
+		" <cffunction name=\"keep\">\n"+
+		" </cffunction>\n"+
+		" </cfcomponent>\n",
+		removalPatch(t, text, lineRange{first: 4, last: 5, name: "old"}))
+}
+
+func TestRemoveSharedLines(t *testing.T) {
+	a := assert.New(t)
+
+	// A function starting on the line the previous one ends on
+	text := "component {\n" +
+		"\tfunction x() {\n" +
+		"\t\treturn 1;\n" +
+		"\t} function y() {\n" +
+		"\t\treturn \"}\";\n" +
+		"\t}\n" +
+		"}\n"
+
+	a.Equal("--- a/x.cfc\n+++ b/x.cfc\n"+
+		"@@ -1,7 +1,5 @@\n"+
+		" component {\n"+
+		" \tfunction x() {\n"+
+		" \t\treturn 1;\n"+
+		"-\t} function y() {\n"+
+		"-\t\treturn \"}\";\n"+
+		"-\t}\n"+
+		"+\t}\n"+
+		" }\n",
+		removalPatch(t, text, lineRange{first: 4, last: 6, name: "y"}))
+
+	// Both functions sharing the line
+	a.Equal("--- a/x.cfc\n+++ b/x.cfc\n"+
+		"@@ -1,7 +1,2 @@\n"+
+		" component {\n"+
+		"-\tfunction x() {\n"+
+		"-\t\treturn 1;\n"+
+		"-\t} function y() {\n"+
+		"-\t\treturn \"}\";\n"+
+		"-\t}\n"+
+		" }\n",
+		removalPatch(t, text, lineRange{first: 2, last: 4, name: "x"}, lineRange{first: 4, last: 6, name: "y"}))
+
+	// A function after the cfscript tag
+	text = "<cfscript>function z() {\n" +
+		"\treturn 1;\n" +
+		"}\n" +
+		"</cfscript>\n"
+
+	a.Equal("--- a/x.cfc\n+++ b/x.cfc\n"+
+		"@@ -1,4 +1,2 @@\n"+
+		"-<cfscript>function z() {\n"+
+		"-\treturn 1;\n"+
+		"-}\n"+
+		"+<cfscript>\n"+
+		" </cfscript>\n",
+		removalPatch(t, text, lineRange{first: 1, last: 3, name: "z"}))
+
+	// The function isn't where the scan found it
+	_, found := locateFunction(strings.SplitAfter(text, "\n"), lineRange{first: 2, last: 3, name: "z"})
+	a.False(found)
+}
+
+func TestScriptFunctionStart(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		code  string
+		name  string
+		start int
+	}{
+		{"public string function getName() {", "getName", 0},
+		{"<cfscript>function init() {", "init", 10},
+		{"} private void function save(x) {", "save", 2},
+	}
+
+	for _, test := range tests {
+		found := regexpScriptFunc.FindStringSubmatchIndex(test.code)
+
+		if a.NotNil(found, test.code) {
+			a.Equal(test.name, test.code[found[2]:found[3]])
+			a.Equal(test.start, scriptFuncStart(test.code, found))
+		}
+	}
+
+	a.Nil(regexpScriptFunc.FindStringSubmatchIndex("x = function(a) {"))
+	a.True((&funcScope{name: "x", script: true, depth: 1, opened: true}).closedBy("\t} "))
+	a.False((&funcScope{name: "x", script: true, depth: 2, opened: true}).closedBy("\t} "))
+}
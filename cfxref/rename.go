@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Regular expression for the name attribute of a cffunction, used to locate the name in the line
+var regexpNameAttr = regexp.MustCompile(`(?i)\bname\s*=\s*"([^"]*)"`)
+
+// Regular expression for a valid method name
+var regexpIdentifier = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// Rename settings from the command line
+var renameTarget string = "" // component.method to rename
+var renameTo string = ""     // New method name
+var renameOutput string = "" // Patch file name (.diff or .patch) or directory for the modified files
+
+// A single replacement of a method name in a line
+type textEdit struct {
+	line   int // Line number (1 based)
+	column int // Position of the name in the line
+}
+
+// renameMethod Rename a method in its definition and at every resolved call site
+func renameMethod() error {
+	// Split the target into the component and the method
+	dotLoc := strings.LastIndex(renameTarget, ".")
+
+	if dotLoc <= 0 || dotLoc == len(renameTarget)-1 {
+		return fmt.Errorf("the method to rename, '%s', must be specified as component.method", renameTarget)
+	}
+
+	if !regexpIdentifier.MatchString(renameTo) {
+		return fmt.Errorf("the new method name '%s' is not a valid name", renameTo)
+	}
+
+	oldName := renameTarget[dotLoc+1:]
+	compKey, err := resolveComponent(normalizeComponent(renameTarget[:dotLoc]), "")
+
+	if err != nil {
+		return err
+	}
+
+	component := xref[compKey]
+	definition, found := component.funcs[strings.ToLower(oldName)]
+
+	if !found {
+		return fmt.Errorf("the method %s was not found in component %s", oldName, component.name)
+	}
+
+	if definition.implicit {
+		return fmt.Errorf("the method %s of component %s is an implicit accessor, rename the property instead", oldName, component.name)
+	}
+
+	if _, found := component.funcs[strings.ToLower(renameTo)]; found {
+		return fmt.Errorf("the component %s already has a method named %s", component.name, renameTo)
+	}
+
+	// Any call that can't be resolved could be a call to this method
+	conflicts := 0
+
+	for _, spec := range dynamicList {
+		if renameConflict(spec, compKey, oldName) {
+			conflicts++
+			fmt.Fprintf(logWriter, "The call in %s at line %d may call %s but is only known at run time\n",
+				spec.fileName, spec.line, oldName)
+		}
+	}
+
+	// Collect the edits for each file starting with the definition
+	edits := make(map[string][]textEdit)
+
+	lines, err := readLines(component.fileName)
+
+	if err != nil {
+		return err
+	}
+
+	column := definitionColumn(lines[definition.line-1], definition, oldName)
+
+	if column < 0 {
+		return fmt.Errorf("the definition of %s could not be found at line %d of %s", oldName, definition.line, component.fileName)
+	}
+
+	edits[component.fileName] = append(edits[component.fileName], textEdit{line: definition.line, column: column})
+
+	for _, spec := range deferredList {
+		if !strings.EqualFold(spec.method, oldName) {
+			continue
+		}
+
+		key, err := resolveComponent(spec.component, spec.fileName)
+
+		if err != nil && isBuiltinComponent(spec.component) {
+			continue
+		}
+
+		if err != nil {
+			// The component isn't known, so this may be a call to the method
+			conflicts++
+			fmt.Fprintf(logWriter, "The call in %s at line %d to %s could not be resolved\n", spec.fileName, spec.line, spec.component)
+			continue
+		}
+
+		if key == compKey {
+			fileName := fullName(spec.fileName)
+			edits[fileName] = append(edits[fileName], textEdit{line: spec.line, column: spec.column})
+		}
+	}
+
+	if conflicts > 0 {
+		return fmt.Errorf("the rename was refused, %d calls could not be resolved and may be affected", conflicts)
+	}
+
+	// Process the files in order so the output is repeatable
+	fileNames := make([]string, 0, len(edits))
+	for fileName := range edits {
+		fileNames = append(fileNames, fileName)
+	}
+
+	sort.Strings(fileNames)
+
+	writeFiles := !strings.HasSuffix(strings.ToLower(renameOutput), ".diff") && !strings.HasSuffix(strings.ToLower(renameOutput), ".patch")
+	var patch strings.Builder
+
+	for _, fileName := range fileNames {
+		lines, err := readLines(fileName)
+
+		if err != nil {
+			return err
+		}
+
+		ranges, err := applyRename(lines, edits[fileName], oldName)
+
+		if err != nil {
+			return fmt.Errorf("%s: %s", fileName, err)
+		}
+
+		relName := relativeName(fileName)
+		fmt.Fprintf(logWriter, "Renaming %s to %s in %s at %d places\n", oldName, renameTo, relName, len(edits[fileName]))
+
+		if writeFiles {
+			// Write the modified file into the output directory
+			for _, span := range ranges {
+				lines[span.first-1] = span.replace[0]
+			}
+
+			outName := filepath.Join(renameOutput, filepath.FromSlash(patchName(relName)))
+
+			if err = os.MkdirAll(filepath.Dir(outName), 0755); err != nil {
+				return err
+			}
+
+			if err = os.WriteFile(outName, []byte(strings.Join(lines, "")), 0644); err != nil {
+				return err
+			}
+		} else {
+			writePatch(&patch, patchName(relName), lines, ranges)
+		}
+	}
+
+	if writeFiles {
+		return nil
+	}
+
+	return os.WriteFile(renameOutput, []byte(patch.String()), 0644)
+}
+
+// renameConflict Check if a call only known at run time could be a call to the method being renamed
+// spec: The dynamic call
+// compKey: Key of the component of the method being renamed
+// oldName: Name of the method being renamed
+func renameConflict(spec defInvoke, compKey string, oldName string) bool {
+	methodKnown := len(spec.method) > 0 && !strings.Contains(spec.method, "#")
+
+	if methodKnown && !strings.EqualFold(spec.method, oldName) {
+		return false
+	}
+
+	// A different known component can't be affected
+	if key, err := resolveComponent(spec.component, spec.fileName); len(spec.component) > 0 && err == nil {
+		return key == compKey
+	}
+
+	return true
+}
+
+// definitionColumn Find the position of the function name in the line that defines it
+func definitionColumn(line string, definition funcDef, oldName string) int {
+	if definition.script {
+		for _, found := range regexpScriptFunc.FindAllStringSubmatchIndex(line, -1) {
+			if strings.EqualFold(line[found[2]:found[3]], oldName) {
+				return found[2]
+			}
+		}
+
+		return -1
+	}
+
+	for _, found := range regexpNameAttr.FindAllStringSubmatchIndex(line, -1) {
+		if strings.EqualFold(line[found[2]:found[3]], oldName) {
+			return found[2]
+		}
+	}
+
+	return -1
+}
+
+// applyRename Build the replacement lines for the edits in a file
+// returns the changed lines as ranges for the patch
+func applyRename(lines []string, edits []textEdit, oldName string) ([]lineRange, error) {
+	// Apply the edits from the end of each line so the earlier positions stay valid
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line == edits[j].line {
+			return edits[i].column > edits[j].column
+		}
+
+		return edits[i].line < edits[j].line
+	})
+
+	ranges := make([]lineRange, 0, len(edits))
+
+	for index, edit := range edits {
+		// The same call may be found more than one way
+		if index > 0 && edit == edits[index-1] {
+			continue
+		}
+
+		if edit.line < 1 || edit.line > len(lines) {
+			return nil, fmt.Errorf("line %d is beyond the end of the file", edit.line)
+		}
+
+		// Edits on the same line build on each other
+		text := lines[edit.line-1]
+		current := len(ranges) - 1
+
+		if current >= 0 && ranges[current].first == edit.line {
+			text = ranges[current].replace[0]
+		}
+
+		end := edit.column + len(oldName)
+
+		if end > len(text) || !strings.EqualFold(text[edit.column:end], oldName) {
+			return nil, fmt.Errorf("the name %s was not found at line %d", oldName, edit.line)
+		}
+
+		text = text[:edit.column] + renameTo + text[end:]
+
+		if current >= 0 && ranges[current].first == edit.line {
+			ranges[current].replace[0] = text
+		} else {
+			ranges = append(ranges, lineRange{first: edit.line, last: edit.line, replace: []string{text}})
+		}
+	}
+
+	return ranges, nil
+}
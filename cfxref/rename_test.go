@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRename(t *testing.T) {
+	a := assert.New(t)
+	savedTo := renameTo
+	defer func() { renameTo = savedTo }()
+	renameTo = "fetchList"
+
+	lines := strings.SplitAfter("<cfscript>\n"+
+		"public array function getList() {\n"+
+		"\treturn this.getList() + getList;\n"+
+		"}\n"+
+		"</cfscript>\n", "\n")
+
+	// The same call found twice is only renamed once, and the later columns are edited first
+	ranges, err := applyRename(lines, []textEdit{{line: 2, column: 22}, {line: 3, column: 13}, {line: 3, column: 13}}, "getList")
+
+	if a.NoError(err) {
+		var patch strings.Builder
+		writePatch(&patch, "/cfc/Members.cfc", lines, ranges)
+
+		a.Equal("--- a/cfc/Members.cfc\n+++ b/cfc/Members.cfc\n"+
+			"@@ -1,5 +1,5 @@\n"+
+			" <cfscript>\n"+
+			"-public array function getList() {\n"+
+			"+public array function fetchList() {\n"+
+			"-\treturn this.getList() + getList;\n"+
+			"+\treturn this.fetchList() + getList;\n"+
+			" }\n"+
+			" </cfscript>\n", patch.String())
+	}
+
+	// The name isn't where the call was found
+	_, err = applyRename(lines, []textEdit{{line: 4, column: 0}}, "getList")
+	a.Error(err)
+}
+
+func TestDefinitionColumn(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(24, definitionColumn("} private void function save() {", funcDef{script: true}, "save"))
+	a.Equal(-1, definitionColumn("function load() {", funcDef{script: true}, "save"))
+	a.Equal(18, definitionColumn(`<cffunction name="save" access="public">`, funcDef{}, "save"))
+}
+
+func TestRenameInvalidName(t *testing.T) {
+	a := assert.New(t)
+	savedTarget, savedTo := renameTarget, renameTo
+	defer func() { renameTarget, renameTo = savedTarget, savedTo }()
+
+	renameTarget = "cfc.Members.getList"
+
+	for _, name := range []string{"1st", "get-list", "get list", "x\"y", ""} {
+		renameTo = name
+		err := renameMethod()
+
+		if a.Error(err, name) {
+			a.Contains(err.Error(), "not a valid name")
+		}
+	}
+}
+
+func TestInvokeColumn(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	savedDeferred := deferredList
+	defer func() { deferredList = savedDeferred }()
+	deferredList = nil
+
+	code := `<cfhttp method="get" url="x"><cfinvoke component="cfc.Members" method="getList">`
+	a.NoError(deferInvoke(regExp.FindAllStringSubmatch(code, -1), "/web/page.cfm", 1, code))
+
+	if a.Len(deferredList, 1) {
+		a.Equal(strings.LastIndex(code, "getList"), deferredList[0].column)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of calls to a component method
+const (
+	callInvoke   = iota // <cfinvoke component="" method="">
+	callMember          // object.method()
+	callFunction        // invoke(object, "method")
+	callRemote          // /CFC/Name.cfc?method=name from a URL or AJAX call
+)
+
+// Regular expression for the method attribute of a cfinvoke, used to locate the name in the line
+var regexpMethodAttr = regexp.MustCompile(`(?i)\bmethod\s*=\s*"([^"]*)"`)
+
+// Regular expression for a variable being assigned a component object.  The groups are
+// 1: variable, 2: createObject("component", name), 3: createObject(name), 4: new name()
+var regexpCreate = regexp.MustCompile(`(?i)(?:\bvar\s+)?([\w.]+)\s*=\s*(?:` +
+	`createObject\s*\(\s*["']component["']\s*,\s*["']([^"'#]+)["']` +
+	`|createObject\s*\(\s*["']([^"'#]+)["']\s*\)` +
+	`|new\s+([\w.]+)\s*\()`)
+
+// Regular expression for a method called directly on a new object.  The groups are
+// 1: createObject("component", name), 2: new name(), 3: the method
+var regexpChained = regexp.MustCompile(`(?i)(?:` +
+	`createObject\s*\(\s*["']component["']\s*,\s*["']([^"'#]+)["']\s*\)` +
+	`|new\s+([\w.]+)\s*\([^)]*\))\s*\.\s*(\w+)\s*\(`)
+
+// Regular expression for a method called on an object variable.  The groups are
+// 1: the object variable, 2: the method
+var regexpMemberCall = regexp.MustCompile(`([\w.]+)\s*\.\s*(\w+)\s*\(`)
+
+// Regular expression for the invoke() function.  The groups are
+// 1: component name, 2: object variable, 3: method name, 4: dynamic method expression
+var regexpInvokeFunc = regexp.MustCompile(`(?i)\binvoke\s*\(\s*(?:["']([^"'#]+)["']|([\w.]+))\s*,\s*(?:["'](\w+)["']|([^,)]+))`)
+
+// Scopes that are shared between files, so an object assigned in one file may be used in another
+var sharedScopes = []string{"application.", "session.", "server.", "request."}
+
+// Method calls on object variables waiting for all the object types to be known
+var pendingCalls = make([]defInvoke, 0, 10000)
+
+// Calls whose component or method cannot be determined
+var dynamicList = make([]defInvoke, 0, 1000)
+
+// Component type of object variables by file (or by name alone for shared scopes)
+var objectTypes = make(map[string]string, 10000)
+
+// Components built into CFML for using tags from script, i.e. new Query()
+var builtinComponents = map[string]interface{}{"collection": nil, "dbinfo": nil, "feed": nil, "ftp": nil, "http": nil,
+	"imap": nil, "index": nil, "ldap": nil, "mail": nil, "pdf": nil, "pop": nil, "query": nil, "search": nil, "storedproc": nil}
+
+// Package of the built in components
+const builtinPackage = "/com/adobe/coldfusion/"
+
+// scanScriptCalls Find the method calls made in script or in expressions in a line of code
+// fileName: Full name of the file being scanned
+// lineNo: Line number in the file
+// code: Text of the line with the comments removed
+func scanScriptCalls(fileName string, lineNo int, code string) {
+	relName := relativeName(fileName)
+	thisComponent := removeSuffix(relName)
+
+	// Remember the types of objects as they are created
+	for _, match := range regexpCreate.FindAllStringSubmatch(code, -1) {
+		component := match[2] + match[3] + match[4]
+		objectTypes[objectKey(relName, match[1])] = normalizeComponent(component)
+	}
+
+	// Methods called directly on a new object
+	chained := regexpChained.FindAllStringSubmatchIndex(code, -1)
+
+	for _, match := range chained {
+		component := subMatch(code, match, 1) + subMatch(code, match, 2)
+		deferredList = append(deferredList, defInvoke{fileName: relName, line: lineNo, kind: callMember,
+			component: normalizeComponent(component), method: subMatch(code, match, 3), column: match[6]})
+	}
+
+	// Methods called on object variables, which are resolved once all the files have been scanned
+	for _, match := range regexpMemberCall.FindAllStringSubmatchIndex(code, -1) {
+		// Skip calls on new objects and the component name in new CFC.Name()
+		if isChained(chained, match[4]) || strings.HasSuffix(strings.ToLower(strings.TrimSpace(code[:match[0]])), "new") {
+			continue
+		}
+
+		receiver := subMatch(code, match, 1)
+		call := defInvoke{fileName: relName, line: lineNo, kind: callMember, method: subMatch(code, match, 2), column: match[4]}
+
+		if strings.EqualFold(receiver, "this") {
+			call.component = thisComponent
+			deferredList = append(deferredList, call)
+		} else {
+			call.component = receiver
+			pendingCalls = append(pendingCalls, call)
+		}
+	}
+
+	// The invoke() function
+	for _, match := range regexpInvokeFunc.FindAllStringSubmatchIndex(code, -1) {
+		call := defInvoke{fileName: relName, line: lineNo, kind: callFunction, method: subMatch(code, match, 3), column: match[6]}
+		receiver := subMatch(code, match, 2)
+
+		if match[2] >= 0 {
+			call.component = normalizeComponent(subMatch(code, match, 1))
+		} else if strings.EqualFold(receiver, "this") {
+			call.component = thisComponent
+		} else {
+			call.component = receiver
+		}
+
+		if match[8] >= 0 {
+			// The method name is an expression
+			call.method = ""
+			call.dynamic = true
+		}
+
+		if match[2] < 0 && !strings.EqualFold(receiver, "this") {
+			pendingCalls = append(pendingCalls, call)
+		} else if call.dynamic {
+			dynamicList = append(dynamicList, call)
+		} else {
+			deferredList = append(deferredList, call)
+		}
+	}
+}
+
+// resolveScriptCalls Resolve the object variables of the pending calls now that all the object types are known.
+// The pending calls are kept so they can be resolved again when files change
+func resolveScriptCalls() {
+	for _, call := range pendingCalls {
+		call.viaObject = true
+		component, found := objectTypes[objectKey(call.fileName, call.component)]
+
+		// Objects of a cfajaxproxy class call the component of the proxy
+		if proxy, isProxy := ajaxProxies[strings.ToLower(component)]; found && isProxy {
+			component = proxy.component
+		} else if call.remote {
+			// Only the calls on proxy objects matter in JavaScript
+			continue
+		}
+
+		if found {
+			call.component = component
+		} else {
+			call.component = ""
+			call.dynamic = true
+		}
+
+		if call.dynamic {
+			dynamicList = append(dynamicList, call)
+		} else {
+			deferredList = append(deferredList, call)
+		}
+	}
+}
+
+// objectKey Build the key for looking up the type of an object variable
+// fileName: File the variable is used in
+// variable: Name of the variable
+func objectKey(fileName string, variable string) string {
+	variable = strings.ToLower(variable)
+	variable = strings.TrimPrefix(strings.TrimPrefix(variable, "variables."), "local.")
+
+	// The shared scopes belong to the application
+	for _, scope := range sharedScopes {
+		if strings.HasPrefix(variable, scope) {
+			return strings.ToLower(qualifier(fileName)) + variable
+		}
+	}
+
+	return strings.ToLower(fileName) + "|" + variable
+}
+
+// normalizeComponent Change a dotted component path (i.e. CFC.Members) into a path from the root
+func normalizeComponent(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+
+	if !strings.Contains(name, "/") && strings.Contains(name, ".") {
+		name = "/" + strings.ReplaceAll(name, ".", "/")
+	}
+
+	return name
+}
+
+// renameOnly Check if a call was found in script, which is only used to find the call sites of a method
+// being renamed and not in the cross reference.  Calls on cfajaxproxy objects are remote calls and count
+func (spec defInvoke) renameOnly() bool {
+	return (spec.kind == callMember || spec.kind == callFunction) && !spec.remote
+}
+
+// isBuiltinComponent Check if a component that wasn't found is one built into CFML
+func isBuiltinComponent(component string) bool {
+	name := strings.TrimPrefix(strings.ToLower(component), builtinPackage)
+	_, found := builtinComponents[name]
+
+	return found
+}
+
+// splitBrowserScript Separate the JavaScript in the <script> blocks of a page from the CFML
+// inScript: The line starts inside a <script> block, updated for the next line
+// code: Text of the line with the comments removed
+// returns the CFML and the JavaScript, each with the other blanked out so the columns are preserved
+func splitBrowserScript(inScript *bool, code string) (string, string) {
+	lower := strings.ToLower(code)
+	cfml := []byte(code)
+	javaScript := []byte(strings.Repeat(" ", len(code)))
+
+	for index := 0; index < len(code); {
+		if !*inScript {
+			start := indexTag(lower[index:], "<script")
+
+			if start < 0 {
+				break
+			}
+
+			// The script starts after the end of the tag
+			index += start
+			end := strings.IndexByte(lower[index:], '>')
+			*inScript = true
+
+			if end < 0 {
+				break
+			}
+
+			index += end + 1
+			continue
+		}
+
+		end := strings.Index(lower[index:], "</script")
+
+		if end < 0 {
+			end = len(code) - index
+		} else {
+			*inScript = false
+		}
+
+		copy(javaScript[index:], code[index:index+end])
+
+		for blank := index; blank < index+end; blank++ {
+			cfml[blank] = ' '
+		}
+
+		index += end
+	}
+
+	return string(cfml), string(javaScript)
+}
+
+// subMatch Get the text of a sub match from the indexes, empty if it didn't participate
+func subMatch(text string, match []int, group int) string {
+	if match[group*2] < 0 {
+		return ""
+	}
+
+	return text[match[group*2]:match[group*2+1]]
+}
+
+// isChained Check if a method name position belongs to a call on a new object
+func isChained(chained [][]int, column int) bool {
+	for _, match := range chained {
+		if match[6] == column {
+			return true
+		}
+	}
+
+	return false
+}
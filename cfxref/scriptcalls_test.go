@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitBrowserScript(t *testing.T) {
+	a := assert.New(t)
+	inScript := false
+
+	cfml, javaScript := splitBrowserScript(&inScript, `<cfset x = a.b()><script>c.d();`)
+	a.Equal(`<cfset x = a.b()><script>      `, cfml)
+	a.Equal(`                         c.d();`, javaScript)
+	a.True(inScript)
+
+	cfml, javaScript = splitBrowserScript(&inScript, `e.f();</script> <cfset g.h()>`)
+	a.Equal(`      </script> <cfset g.h()>`, cfml)
+	a.Equal(`e.f();                       `, javaScript)
+	a.False(inScript)
+
+	// Not a script tag
+	cfml, _ = splitBrowserScript(&inScript, `<scripts>x.y()`)
+	a.Equal(`<scripts>x.y()`, cfml)
+	a.False(inScript)
+}
+
+func TestBuiltinComponents(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+
+	savedDeferred, savedWriter, savedCt := deferredList, missingWriter, missingFuncCt
+	defer func() { deferredList, missingWriter, missingFuncCt = savedDeferred, savedWriter, savedCt }()
+
+	var err error
+	missingWriter, err = os.CreateTemp(t.TempDir(), "missing")
+	a.NoError(err)
+	defer missingWriter.Close()
+
+	a.True(isBuiltinComponent("Query"))
+	a.True(isBuiltinComponent("/com/adobe/coldfusion/http"))
+	a.False(isBuiltinComponent("/cfc/Query"))
+
+}
+
+func TestScriptCallsRenameOnly(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+
+	savedDeferred, savedPending, savedWriter, savedCt := deferredList, pendingCalls, missingWriter, missingFuncCt
+	defer func() {
+		deferredList, pendingCalls, missingWriter, missingFuncCt = savedDeferred, savedPending, savedWriter, savedCt
+	}()
+
+	var err error
+	missingWriter, err = os.CreateTemp(t.TempDir(), "missing")
+	a.NoError(err)
+	defer missingWriter.Close()
+
+	// The script calls are kept for a rename but are not reported as missing
+	deferredList, pendingCalls = nil, nil
+	missingFuncCt = 0
+	scanScriptCalls("/web/page.cfm", 1, `<cfset q = new Query(sql="select 1")><cfset r = q.execute()>`)
+	scanScriptCalls("/web/page.cfm", 2, `<cfset m = new Missing()><cfset m.run()>`)
+	resolveScriptCalls()
+	processInvoke()
+
+	a.Len(deferredList, 2)
+	for _, spec := range deferredList {
+		a.True(spec.renameOnly())
+	}
+
+	a.Equal(0, missingFuncCt)
+
+	// A cfinvoke, a remote call and a call on a cfajaxproxy object are in the cross reference
+	a.False(defInvoke{kind: callInvoke}.renameOnly())
+	a.False(defInvoke{kind: callRemote, remote: true}.renameOnly())
+	a.False(defInvoke{kind: callMember, remote: true}.renameOnly())
+}
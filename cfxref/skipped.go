@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Reasons a component was not indexed
+const (
+	reasonSkipped  = "in a skipped directory"
+	reasonExcluded = "excluded"
+)
+
+// Directories skipped while walking the tree (full path names)
+var skippedDirs = make(map[string]interface{}, 10)
+
+// Components that exist but were not indexed, with the reason, by component key
+var unindexed = make(map[string]string, 100)
+
+// Index the components in skipped directories for their definitions only
+var indexSkipped bool = false
+
+// The file being parsed only adds definitions, its calls are not recorded
+var definitionsOnly bool = false
+
+// Counters for the references to components that were not indexed
+var skippedRefCt int = 0  // References to components in skipped directories
+var excludedRefCt int = 0 // References to excluded components
+
+// processSkipped Find the components in the skipped directories, indexing their definitions if requested
+func processSkipped() error {
+	dirs := make([]string, 0, len(skippedDirs))
+	for dir := range skippedDirs {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		root := rootOf(dir)
+
+		if root == nil {
+			continue
+		}
+
+		enterRoot(root)
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, callerErr error) error {
+			if info == nil || callerErr != nil || info.IsDir() || !regexpFName.MatchString(filepath.Base(path)) {
+				return nil
+			}
+
+			mapName := componentKey(path)
+
+			if _, found := unindexed[mapName]; !found {
+				unindexed[mapName] = reasonSkipped
+			}
+
+			if !indexSkipped {
+				return nil
+			}
+
+			// Only the definitions are wanted so calls into the directory resolve
+			definitionsOnly = true
+			err := parseFile(path)
+			definitionsOnly = false
+
+			if component, found := xref[mapName]; found && component.fileName == path {
+				component.definitionOnly = true
+				xref[mapName] = component
+				delete(unindexed, mapName)
+			}
+
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// componentKey Get the key of the component for a file
+func componentKey(fileName string) string {
+	return strings.ToLower(removeSuffix(relativeName(fileName)))
+}
+
+// unindexedReason Get the reason a referenced component was not indexed, empty if it doesn't exist
+// caller: Relative name of the file making the call
+func unindexedReason(compName string, caller string) string {
+	for _, key := range componentCandidates(compName, caller) {
+		if reason, found := unindexed[key]; found {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+// reportUnindexed Report a reference to a component that exists but was not indexed
+// returns false when the component doesn't exist
+func reportUnindexed(spec defInvoke) bool {
+	reason := unindexedReason(spec.component, spec.fileName)
+
+	switch reason {
+	case reasonSkipped:
+		skippedRefCt++
+	case reasonExcluded:
+		excludedRefCt++
+	default:
+		return false
+	}
+
+	fmt.Fprintf(missingWriter, "The component %s referenced in %s at line %d is %s\n", spec.component, spec.fileName, spec.line, reason)
+
+	return true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useSkipped Set up a web root with a skipped directory and an excluded component for a test
+func useSkipped(t *testing.T) string {
+	dir := t.TempDir()
+	useRoot(t, dir)
+	enterRoot(roots[0])
+	useXref(t)
+
+	savedDeferred, savedDirs, savedUnindexed, savedExcludes := deferredList, skippedDirs, unindexed, excludes
+	savedWriter, savedIndex := missingWriter, indexSkipped
+	savedCts := []int{missingFuncCt, missingMethodCt, skippedRefCt, excludedRefCt}
+	t.Cleanup(func() {
+		deferredList, skippedDirs, unindexed, excludes = savedDeferred, savedDirs, savedUnindexed, savedExcludes
+		missingWriter, indexSkipped = savedWriter, savedIndex
+		missingFuncCt, missingMethodCt, skippedRefCt, excludedRefCt = savedCts[0], savedCts[1], savedCts[2], savedCts[3]
+	})
+
+	deferredList, unindexed = nil, make(map[string]string)
+	missingFuncCt, missingMethodCt, skippedRefCt, excludedRefCt = 0, 0, 0, 0
+	skippedDirs = map[string]interface{}{filepath.Join(dir, "lib"): nil}
+	excludes = []string{"/legacy/old.cfc"}
+
+	files := map[string]string{
+		"lib/Util.cfc": "<cfcomponent>\n<cffunction name=\"run\" access=\"public\">\n" +
+			"<cfinvoke component=\"Gone\" method=\"x\">\n</cffunction>\n</cfcomponent>\n",
+		"legacy/Old.cfc": "<cfcomponent>\n<cffunction name=\"run\" access=\"public\"></cffunction>\n</cfcomponent>\n",
+		"index.cfm": "<cfinvoke component=\"lib.Util\" method=\"run\">\n" +
+			"<cfinvoke component=\"legacy.Old\" method=\"run\">\n",
+	}
+
+	for name, content := range files {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(fileName, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output, err := os.CreateTemp(t.TempDir(), "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { output.Close() })
+	missingWriter = output
+
+	return dir
+}
+
+func TestProcessSkippedUnindexed(t *testing.T) {
+	a := assert.New(t)
+	dir := useSkipped(t)
+	indexSkipped = false
+
+	a.NoError(parseFile(filepath.Join(dir, "legacy", "Old.cfc")))
+	a.NoError(parseFile(filepath.Join(dir, "index.cfm")))
+	a.NoError(processSkipped())
+	processInvoke()
+
+	a.Equal(map[string]string{"/lib/util": reasonSkipped, "/legacy/old": reasonExcluded}, unindexed)
+
+	// The components are reported with the reason they were not indexed, not as missing
+	a.Equal(0, missingFuncCt)
+	a.Equal(1, skippedRefCt)
+	a.Equal(1, excludedRefCt)
+
+	report, err := os.ReadFile(missingWriter.Name())
+	if a.NoError(err) {
+		a.Equal([]string{
+			"The component /lib/Util referenced in /index.cfm at line 1 is in a skipped directory",
+			"The component /legacy/Old referenced in /index.cfm at line 2 is excluded",
+		}, strings.Split(strings.TrimSpace(string(report)), "\n"))
+	}
+}
+
+func TestProcessSkippedDefinitionsOnly(t *testing.T) {
+	a := assert.New(t)
+	dir := useSkipped(t)
+	indexSkipped = true
+
+	a.NoError(parseFile(filepath.Join(dir, "legacy", "Old.cfc")))
+	a.NoError(parseFile(filepath.Join(dir, "index.cfm")))
+	a.NoError(processSkipped())
+	processInvoke()
+
+	// The definitions resolve the calls into the directory
+	if a.Contains(xref, "/lib/util") {
+		a.True(xref["/lib/util"].definitionOnly)
+		a.Contains(xref["/lib/util"].funcs["run"].usedBy, "/index.cfm")
+	}
+
+	a.NotContains(unindexed, "/lib/util")
+	a.Equal(0, skippedRefCt)
+
+	// The calls made by the skipped component are not counted
+	for _, spec := range deferredList {
+		a.Equal("/index.cfm", spec.fileName)
+	}
+
+	a.Equal(0, missingFuncCt)
+	a.False(definitionsOnly)
+}
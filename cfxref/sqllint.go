@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SQL lint scanning states
+const (
+	sqlOutside  = iota // Not inside a cfquery
+	sqlOpenTag         // Inside the opening <cfquery ...> tag
+	sqlBody            // Inside the body of the cfquery
+	sqlInnerTag        // Inside a cf tag (such as cfqueryparam) within the cfquery body
+)
+
+// Regular expression for a call to preserveSingleQuotes() capturing the argument
+var findPreserve = regexp.MustCompile(`(?i)preserveSingleQuotes\s*\(\s*([^)]*)\)`)
+
+// SQL lint findings by file, kept so the report can be written again when watching
+var sqlFindings = make(map[string][]string)
+
+// SQL lint state for the file currently being scanned
+type sqlState struct {
+	state    int    // Current scanning state
+	funcName string // Name of the enclosing cffunction, if any
+}
+
+// lintSQL Check a line for #variable# interpolations in a cfquery body that are not wrapped in
+// a cfqueryparam as well as any use of preserveSingleQuotes()
+// lint: Scanning state for the file
+// fileName: Name of the file being scanned
+// lineNo: Line number of the text
+// text: The text of the line
+func lintSQL(lint *sqlState, fileName string, lineNo int, text string) {
+	// preserveSingleQuotes defeats the quote escaping, flag it wherever it is
+	for _, match := range findPreserve.FindAllStringSubmatch(text, -1) {
+		reportSQL(lint, fileName, lineNo, "preserveSingleQuotes("+strings.TrimSpace(match[1])+")")
+	}
+
+	lower := strings.ToLower(text)
+	pos := 0
+
+	for pos < len(text) {
+		switch lint.state {
+		case sqlOutside:
+			// Look for the start of a query
+			start := indexTag(lower[pos:], "<cfquery")
+
+			if start < 0 {
+				return
+			}
+
+			pos += start + len("<cfquery")
+			lint.state = sqlOpenTag
+
+		case sqlOpenTag, sqlInnerTag:
+			// Hashes inside a tag are attribute values and not SQL text, so skip to the end of the tag
+			end := strings.IndexByte(text[pos:], '>')
+
+			if end < 0 {
+				return
+			}
+
+			pos += end + 1
+			lint.state = sqlBody
+
+		case sqlBody:
+			// Find the next tag within the body
+			next := strings.IndexByte(text[pos:], '<')
+			segment := text[pos:]
+
+			if next >= 0 {
+				segment = text[pos : pos+next]
+			}
+
+			// Any hash variables in the SQL text itself are unparameterized
+			for _, spec := range findVars.FindAllString(segment, -1) {
+				expression := strings.TrimSpace(spec[1 : len(spec)-1])
+
+				// Skip escaped hashes (##), preserveSingleQuotes (already reported) and known safe expressions
+				if len(expression) == 0 || findPreserve.MatchString(expression) || isSQLAllowed(expression) {
+					continue
+				}
+
+				reportSQL(lint, fileName, lineNo, spec)
+			}
+
+			if next < 0 {
+				return
+			}
+
+			pos += next
+
+			if strings.HasPrefix(lower[pos:], "</cfquery") {
+				// End of the query
+				pos += len("</cfquery")
+				lint.state = sqlOutside
+			} else if strings.HasPrefix(lower[pos:], "<cf") || strings.HasPrefix(lower[pos:], "</cf") {
+				// A nested CF tag such as cfqueryparam, cfif or cfloop
+				pos++
+				lint.state = sqlInnerTag
+			} else {
+				// Just a less than sign in the SQL
+				pos++
+			}
+		}
+	}
+}
+
+// indexTag Find the start of a tag name that is followed by whitespace or the end of the tag
+// text: Lower case text to search
+// tag: Lower case tag opening (i.e. <cfquery)
+func indexTag(text string, tag string) int {
+	offset := 0
+
+	for {
+		index := strings.Index(text[offset:], tag)
+
+		if index < 0 {
+			return -1
+		}
+
+		index += offset
+		after := index + len(tag)
+
+		// Make sure it's not a longer tag name (i.e. <cfqueryparam)
+		if after >= len(text) || strings.ContainsRune(" \t\r\n>/", rune(text[after])) {
+			return index
+		}
+
+		offset = after
+	}
+}
+
+// isSQLAllowed Check if a variable expression matches one of the allowed patterns
+func isSQLAllowed(expression string) bool {
+	for _, pattern := range sqlAllow {
+		if pattern.MatchString(expression) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reportSQL Report a single SQL lint finding
+func reportSQL(lint *sqlState, fileName string, lineNo int, expression string) {
+	funcName := lint.funcName
+
+	if len(funcName) == 0 {
+		funcName = "(none)"
+	}
+
+	finding := fmt.Sprintf("Possible SQL injection in %s at line %d: %s in function %s\n",
+		relativeName(fileName), lineNo, expression, funcName)
+
+	sqlLintCt++
+	sqlFindings[fileName] = append(sqlFindings[fileName], finding)
+	fmt.Fprint(sqlWriter, finding)
+}
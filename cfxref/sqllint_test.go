@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintSQL(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	savedWriter, savedAllow, savedCt := sqlWriter, sqlAllow, sqlLintCt
+	t.Cleanup(func() {
+		sqlWriter, sqlAllow, sqlLintCt = savedWriter, savedAllow, savedCt
+	})
+
+	outName := filepath.Join(t.TempDir(), "sql.txt")
+	output, err := os.Create(outName)
+	if !a.NoError(err) {
+		return
+	}
+
+	sqlWriter, sqlLintCt = output, 0
+	sqlAllow = []*regexp.Regexp{regexp.MustCompile(`(?i)^application\.dsn$`)}
+
+	lint := sqlState{funcName: "getMember"}
+	lines := []string{
+		`<cfquery name="q" datasource="#application.dsn#">`,
+		`SELECT * FROM members WHERE id = #url.id#`,
+		`AND name = <cfqueryparam value="#form.name#"> AND x < 5 AND tag = '##'`,
+		`<cfif len(#form.sort#)>ORDER BY #form.sort#</cfif> AND db = #application.dsn#`,
+		`</cfquery> <cfset total = #count#>`,
+		`<cfqueryparam value="#outside#"> #preserveSingleQuotes(sql)#`,
+	}
+
+	for index, line := range lines {
+		lintSQL(&lint, "/web/CFC/Members.cfc", index+1, line)
+	}
+
+	output.Close()
+	report, err := os.ReadFile(outName)
+
+	if a.NoError(err) {
+		a.Equal([]string{
+			"Possible SQL injection in /CFC/Members.cfc at line 2: #url.id# in function getMember",
+			"Possible SQL injection in /CFC/Members.cfc at line 4: #form.sort# in function getMember",
+			"Possible SQL injection in /CFC/Members.cfc at line 6: preserveSingleQuotes(sql) in function getMember",
+		}, strings.Split(strings.TrimSpace(string(report)), "\n"))
+	}
+
+	a.Equal(3, sqlLintCt)
+	a.Equal(sqlOutside, lint.state)
+}
+
+func TestIndexTag(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal(-1, indexTag("<cfqueryparam value=1>", "<cfquery"))
+	a.Equal(14, indexTag("<cfqueryparam><cfquery name=q>", "<cfquery"))
+	a.Equal(0, indexTag("<cfquery", "<cfquery"))
+	a.Equal(0, indexTag("<cfquery>", "<cfquery"))
+}
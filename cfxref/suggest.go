@@ -0,0 +1,143 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Most names suggested for a missing component or method
+const maxSuggestions = 3
+
+// Suggestions already worked out for the missing components, by lower case qualifier and component name
+var componentSuggestions = make(map[string]string, 100)
+
+// suggestComponent Get the closest component names to a missing one, searching the same places as the lookup
+// caller: Relative name of the file making the call
+// returns the text to add to the missing report, empty when nothing is close
+func suggestComponent(compName string, caller string) string {
+	cacheKey := strings.ToLower(qualifier(caller) + compName)
+
+	if text, found := componentSuggestions[cacheKey]; found {
+		return text
+	}
+
+	candidates := componentCandidates(compName, caller)
+	best := make(map[string]int)
+
+	for key, component := range xref {
+		for _, candidate := range candidates {
+			if distance, near := nameDistance(candidate, key); near {
+				if previous, found := best[component.name]; !found || distance < previous {
+					best[component.name] = distance
+				}
+			}
+		}
+	}
+
+	text := suggestionText(best)
+	componentSuggestions[cacheKey] = text
+
+	return text
+}
+
+// suggestMethod Get the closest method names in a component to a missing one
+// returns the text to add to the missing report, empty when nothing is close
+func suggestMethod(funcs map[string]funcDef, method string) string {
+	best := make(map[string]int)
+
+	for key, function := range funcs {
+		if distance, near := nameDistance(strings.ToLower(method), key); near {
+			best[function.name] = distance
+		}
+	}
+
+	return suggestionText(best)
+}
+
+// suggestionText Format the closest names, i.e. ", did you mean getMemberList?"
+// best: Edit distance by name
+func suggestionText(best map[string]int) string {
+	if len(best) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(best))
+	for name := range best {
+		names = append(names, name)
+	}
+
+	// Closest first, then by name so the report is repeatable
+	sort.Slice(names, func(i, j int) bool {
+		if best[names[i]] != best[names[j]] {
+			return best[names[i]] < best[names[j]]
+		}
+
+		return names[i] < names[j]
+	})
+
+	if len(names) > maxSuggestions {
+		names = names[:maxSuggestions]
+	}
+
+	return ", did you mean " + strings.Join(names, " or ") + "?"
+}
+
+// nameDistance Get the edit distance between two lower case names
+// returns false when the names are too far apart for one to be a mistake for the other
+func nameDistance(name string, other string) (int, bool) {
+	// A quarter of the name may be wrong
+	limit := len(name) / 4
+	if limit < 1 {
+		limit = 1
+	}
+
+	sizeDiff := len(name) - len(other)
+	if sizeDiff < 0 {
+		sizeDiff = -sizeDiff
+	}
+
+	// Too far apart by the lengths alone
+	if sizeDiff > limit {
+		return 0, false
+	}
+
+	distance := editDistance(name, other)
+
+	return distance, distance <= limit
+}
+
+// editDistance Get the Levenshtein distance between two strings, the number of characters to insert, delete or
+// change to turn one into the other
+func editDistance(first string, second string) int {
+	previous := make([]int, len(second)+1)
+	current := make([]int, len(second)+1)
+
+	for col := range previous {
+		previous[col] = col
+	}
+
+	for row := 1; row <= len(first); row++ {
+		current[0] = row
+
+		for col := 1; col <= len(second); col++ {
+			cost := 1
+			if first[row-1] == second[col-1] {
+				cost = 0
+			}
+
+			current[col] = previous[col-1] + cost
+
+			if previous[col]+1 < current[col] {
+				current[col] = previous[col] + 1
+			}
+
+			if current[col-1]+1 < current[col] {
+				current[col] = current[col-1] + 1
+			}
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(second)]
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameDistance(t *testing.T) {
+	a := assert.New(t)
+
+	// A quarter of the name may be wrong
+	distance, near := nameDistance("getmemberlist", "getmembrelist")
+	a.True(near)
+	a.Equal(2, distance)
+
+	_, near = nameDistance("getmemberlist", "getmemblist")
+	a.True(near)
+
+	_, near = nameDistance("getmemberlist", "getmemlist")
+	a.True(near)
+
+	_, near = nameDistance("getmemberlist", "getlist")
+	a.False(near)
+
+	_, near = nameDistance("getmemberlist", "setnemberlast")
+	a.True(near)
+
+	_, near = nameDistance("getmemberlist", "setnumbertest")
+	a.False(near)
+
+	// Short names may still have one mistake
+	_, near = nameDistance("run", "ran")
+	a.True(near)
+
+	_, near = nameDistance("run", "set")
+	a.False(near)
+}
+
+func TestSuggestComponent(t *testing.T) {
+	a := assert.New(t)
+	useRoot(t, "/web")
+	useXref(t)
+
+	savedSuggestions := componentSuggestions
+	defer func() { componentSuggestions = savedSuggestions }()
+	componentSuggestions = make(map[string]string)
+
+	for _, name := range []string{"/cfc/Members", "/cfc/MemberA", "/cfc/MemberB", "/cfc/Orders"} {
+		xref[strings.ToLower(name)] = compDef{name: name, funcs: make(map[string]funcDef)}
+	}
+
+	// The case of the names doesn't matter
+	a.Equal(", did you mean /cfc/Orders?", suggestComponent("/CFC/ORDRES", "/index.cfm"))
+
+	// Candidates the same distance away are listed by name
+	for i := 0; i < 5; i++ {
+		componentSuggestions = make(map[string]string)
+		a.Equal(", did you mean /cfc/MemberA or /cfc/MemberB or /cfc/Members?", suggestComponent("/cfc/MemberC", "/index.cfm"))
+	}
+
+	// Nothing is close
+	a.Empty(suggestComponent("/util/Strings", "/index.cfm"))
+}
+
+func TestSuggestMethod(t *testing.T) {
+	a := assert.New(t)
+	funcs := map[string]funcDef{"getlist": {name: "getList"}, "getlast": {name: "getLast"}, "save": {name: "save"}}
+
+	a.Equal(", did you mean getList or getLast?", suggestMethod(funcs, "GETLIST1"))
+	a.Equal(", did you mean save?", suggestMethod(funcs, "Sav"))
+	a.Empty(suggestMethod(funcs, "delete"))
+}
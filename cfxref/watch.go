@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Seconds between checks for changed files (0 when not watching)
+var watchInterval int = 0
+
+// watchTree Keep checking the web root for changed files, re-indexing them and re-emitting the reports,
+// until the program is interrupted
+func watchTree() {
+	fileTimes, dirs := listTree()
+	interval := time.Duration(watchInterval) * time.Second
+
+	// Stop cleanly so the output files are closed
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	watch := newWatcher(dirs)
+	defer watch.close()
+
+	fmt.Fprintf(os.Stdout, "Watching %d files for changes every %d seconds\n", len(fileTimes), watchInterval)
+
+	for {
+		if !watch.wait(interval, stop) {
+			fmt.Fprintf(os.Stdout, "Stopped watching for changes\n")
+			return
+		}
+
+		// Compare the files against the previous look, watching any new directories
+		currentTimes, currentDirs := listTree()
+		watch.add(currentDirs)
+		changed := make([]string, 0)
+
+		for path, modified := range currentTimes {
+			if previous, found := fileTimes[path]; !found || !previous.Equal(modified) {
+				changed = append(changed, path)
+			}
+		}
+
+		for path := range fileTimes {
+			if _, found := currentTimes[path]; !found {
+				changed = append(changed, path)
+			}
+		}
+
+		fileTimes = currentTimes
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		sort.Strings(changed)
+
+		// Remember the results before the change
+		oldOrphans := orphanSet()
+		oldMissing := missingRefs
+
+		// Re-index the changed files
+		for _, path := range changed {
+			fmt.Fprintf(os.Stdout, "Changed: %s\n", relativeName(path))
+			unindexFile(path)
+
+			if _, found := currentTimes[path]; found {
+				if root := rootOf(path); root != nil {
+					enterRoot(root)
+				}
+
+				if err := parseFile(path); err != nil {
+					fmt.Fprintln(logWriter, err)
+				}
+			}
+		}
+
+		synthesizeAccessors()
+		processProviders()
+		reanalyze()
+
+		// Show what changed since the previous run
+		showChanges("Newly orphaned", orphanSet(), oldOrphans)
+		showChanges("No longer orphaned", oldOrphans, orphanSet())
+		showChanges("Newly missing", missingRefs, oldMissing)
+		showChanges("Newly resolved", oldMissing, missingRefs)
+
+		fmt.Fprintf(os.Stdout, "Re-indexed %d files: %d orphaned functions, %d missing functions, %d missing methods\n",
+			len(changed), orphanFuncCt, missingFuncCt, missingMethodCt)
+	}
+}
+
+// wait Wait until a watched directory changes or the interval passes
+// stop: Signalled when the program is interrupted
+// returns false when interrupted
+func (w *watcher) wait(interval time.Duration, stop chan os.Signal) bool {
+	select {
+	case <-stop:
+		return false
+	case <-time.After(interval):
+		return true
+	case <-w.changed:
+	}
+
+	// Give an editor time to finish writing
+	select {
+	case <-stop:
+		return false
+	case <-time.After(500 * time.Millisecond):
+		return true
+	}
+}
+
+// listTree Get the modified time for each file to process and the list of directories
+func listTree() (map[string]time.Time, []string) {
+	fileTimes := make(map[string]time.Time, len(xref))
+	dirs := make([]string, 0, 100)
+
+	walkRoots(func(path string, info os.FileInfo, callerErr error) error {
+		process, result := selectPath(path, info, callerErr)
+
+		if process {
+			fileTimes[path] = info.ModTime()
+		} else if result == nil && info != nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+
+		return result
+	})
+
+	return fileTimes, dirs
+}
+
+// unindexFile Remove everything that was found in a file so it can be scanned again
+func unindexFile(path string) {
+	relName := relativeName(path)
+	mapName := strings.ToLower(removeSuffix(relName))
+
+	if component, found := xref[mapName]; found && component.fileName == path {
+		totalFuncs -= len(component.funcs)
+		delete(xref, mapName)
+	}
+
+	delete(componentAttrs, mapName)
+	delete(properties, mapName)
+	delete(sqlFindings, path)
+
+	// Remove the object variables that are local to the file
+	prefix := strings.ToLower(relName) + "|"
+	for key := range objectTypes {
+		if strings.HasPrefix(key, prefix) {
+			delete(objectTypes, key)
+		}
+	}
+
+	// Remove the cfajaxproxy classes created by the file
+	for className, proxy := range ajaxProxies {
+		if proxy.fileName == relName {
+			delete(ajaxProxies, className)
+		}
+	}
+
+	// Remove the calls made by the file
+	inFile := func(spec defInvoke) bool {
+		return spec.fileName == relName
+	}
+
+	deferredList = removeCalls(deferredList, inFile)
+	dynamicList = removeCalls(dynamicList, inFile)
+	pendingCalls = removeCalls(pendingCalls, inFile)
+	commentedList = removeCalls(commentedList, inFile)
+}
+
+// removeCalls Remove the calls matching a condition from a list
+func removeCalls(list []defInvoke, remove func(defInvoke) bool) []defInvoke {
+	kept := list[:0]
+
+	for _, spec := range list {
+		if !remove(spec) {
+			kept = append(kept, spec)
+		}
+	}
+
+	return kept
+}
+
+// reanalyze Resolve all the calls again and re-emit the orphan and missing reports
+func reanalyze() {
+	// The calls resolved from object variables are resolved again since the object types may have changed
+	resolved := func(spec defInvoke) bool {
+		return spec.viaObject
+	}
+
+	deferredList = removeCalls(deferredList, resolved)
+	dynamicList = removeCalls(dynamicList, resolved)
+
+	// Clear the usage and the results
+	for _, component := range xref {
+		for key, function := range component.funcs {
+			function.usedBy = make(map[string]funcUsage)
+			component.funcs[key] = function
+		}
+	}
+
+	orphans = make(map[string][]string)
+	missingRefs = make(map[string]interface{})
+	componentSuggestions = make(map[string]string)
+	missingFuncCt, missingMethodCt, orphanCompCt, orphanFuncCt = 0, 0, 0, 0
+	skippedRefCt, excludedRefCt = 0, 0
+
+	for _, writer := range []*os.File{missingWriter, orphanWriter, sqlWriter} {
+		if err := rewind(writer); err != nil {
+			fmt.Fprintln(logWriter, err)
+		}
+	}
+
+	// The SQL lint findings of the files that did not change are not found again
+	sqlLintCt = 0
+	fileNames := make([]string, 0, len(sqlFindings))
+
+	for fileName := range sqlFindings {
+		fileNames = append(fileNames, fileName)
+	}
+
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+		for _, finding := range sqlFindings[fileName] {
+			sqlLintCt++
+			fmt.Fprint(sqlWriter, finding)
+		}
+	}
+
+	resolveScriptCalls()
+	processInvoke()
+	processOrphans()
+
+	if gitAge {
+		processOrphanAges()
+	}
+
+	displayOrphans()
+}
+
+// rewind Start a report file over so it only holds the latest results
+func rewind(writer *os.File) error {
+	if writer == os.Stderr || writer == os.Stdout {
+		return nil
+	}
+
+	if err := writer.Truncate(0); err != nil {
+		return fmt.Errorf("unable to rewind %s: %s", writer.Name(), err)
+	}
+
+	if _, err := writer.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to rewind %s: %s", writer.Name(), err)
+	}
+
+	return nil
+}
+
+// orphanSet Get the current orphans as a set of component.function names
+func orphanSet() map[string]interface{} {
+	result := make(map[string]interface{}, orphanFuncCt)
+
+	for componentName, functions := range orphans {
+		for _, functionName := range functions {
+			result[componentName+"."+functionName] = nil
+		}
+	}
+
+	return result
+}
+
+// showChanges Display the entries in the current set that were not in the previous set
+func showChanges(title string, current map[string]interface{}, previous map[string]interface{}) {
+	added := make([]string, 0)
+
+	for key := range current {
+		if _, found := previous[key]; !found {
+			added = append(added, key)
+		}
+	}
+
+	sort.Strings(added)
+
+	for _, key := range added {
+		fmt.Fprintf(os.Stdout, "%s: %s\n", title, key)
+	}
+}
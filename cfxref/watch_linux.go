@@ -0,0 +1,113 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Events on the watched directories that may change the files to process
+const watchEvents = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// Watches on the directories of the tree using inotify.  They are kept for the whole watch so the events
+// between checks are not lost
+type watcher struct {
+	fd      int                    // The inotify instance
+	notify  *os.File               // File reading the events (nil when inotify isn't available)
+	lock    sync.Mutex             // Protects the watch maps, which the event reader also updates
+	dirs    map[int32]string       // Directory of each watch descriptor
+	watched map[string]interface{} // Directories being watched
+	changed chan interface{}       // Signalled when there are events
+}
+
+// newWatcher Start watching the directories, falling back to polling when inotify isn't available
+func newWatcher(dirs []string) *watcher {
+	w := &watcher{dirs: make(map[int32]string), watched: make(map[string]interface{}), changed: make(chan interface{}, 1)}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+
+	if err != nil {
+		return w
+	}
+
+	w.fd = fd
+	w.notify = os.NewFile(uintptr(fd), "inotify")
+	w.add(dirs)
+
+	go w.read()
+
+	return w
+}
+
+// add Watch the directories that are not watched yet
+func (w *watcher) add(dirs []string) {
+	if w.notify == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, dir := range dirs {
+		if _, found := w.watched[dir]; found {
+			continue
+		}
+
+		if wd, err := syscall.InotifyAddWatch(w.fd, dir, watchEvents); err == nil {
+			w.dirs[int32(wd)] = dir
+			w.watched[dir] = nil
+		}
+	}
+}
+
+// read Read the events until the watcher is closed, watching the directories as they are created
+func (w *watcher) read() {
+	events := make([]byte, 64*1024)
+
+	for {
+		size, err := w.notify.Read(events)
+
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= size; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&events[offset]))
+			name := strings.TrimRight(string(events[offset+syscall.SizeofInotifyEvent:offset+syscall.SizeofInotifyEvent+int(event.Len)]), "\x00")
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			w.lock.Lock()
+			dir := w.dirs[event.Wd]
+
+			// The watch is gone with its directory, which may be created again
+			if event.Mask&syscall.IN_IGNORED != 0 {
+				delete(w.dirs, event.Wd)
+				delete(w.watched, dir)
+			}
+
+			w.lock.Unlock()
+
+			if event.Mask&syscall.IN_CREATE != 0 && event.Mask&syscall.IN_ISDIR != 0 && len(dir) > 0 {
+				w.add([]string{filepath.Join(dir, name)})
+			}
+		}
+
+		// The changes themselves are found by comparing the file times
+		select {
+		case w.changed <- nil:
+		default:
+		}
+	}
+}
+
+// close Stop watching
+func (w *watcher) close() {
+	if w.notify != nil {
+		w.notify.Close()
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package main
+
+// Without inotify the tree is polled, so there are never any events
+type watcher struct {
+	changed chan interface{} // Signalled when there are events (never)
+}
+
+// newWatcher Setup polling the directories
+// dirs: Directories to watch (not used when polling)
+func newWatcher(dirs []string) *watcher {
+	return &watcher{changed: make(chan interface{})}
+}
+
+// add Watch more directories (not used when polling)
+func (w *watcher) add(dirs []string) {
+}
+
+// close Stop watching
+func (w *watcher) close() {
+}
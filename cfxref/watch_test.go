@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReanalyze(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+	useRoot(t, dir)
+	enterRoot(roots[0])
+	useXref(t)
+
+	savedDeferred, savedDynamic, savedPending := deferredList, dynamicList, pendingCalls
+	savedOrphans, savedMissing, savedFindings := orphans, missingRefs, sqlFindings
+	savedWriters := []*os.File{orphanWriter, missingWriter, sqlWriter, logWriter}
+	savedCts := []int{missingFuncCt, missingMethodCt, orphanCompCt, orphanFuncCt, sqlLintCt}
+	t.Cleanup(func() {
+		deferredList, dynamicList, pendingCalls = savedDeferred, savedDynamic, savedPending
+		orphans, missingRefs, sqlFindings = savedOrphans, savedMissing, savedFindings
+		orphanWriter, missingWriter, sqlWriter, logWriter = savedWriters[0], savedWriters[1], savedWriters[2], savedWriters[3]
+		missingFuncCt, missingMethodCt, orphanCompCt, orphanFuncCt, sqlLintCt = savedCts[0], savedCts[1], savedCts[2], savedCts[3], savedCts[4]
+	})
+
+	deferredList, dynamicList, pendingCalls = nil, nil, nil
+	sqlFindings = make(map[string][]string)
+	sqlLintCt = 0
+
+	for _, writer := range []**os.File{&orphanWriter, &missingWriter, &sqlWriter, &logWriter} {
+		file, err := os.CreateTemp(t.TempDir(), "report")
+		if !a.NoError(err) {
+			return
+		}
+
+		defer file.Close()
+		*writer = file
+	}
+
+	component := filepath.Join(dir, "cfc", "Members.cfc")
+	page := filepath.Join(dir, "index.cfm")
+	a.NoError(os.Mkdir(filepath.Dir(component), 0755))
+
+	a.NoError(os.WriteFile(component, []byte("<cfcomponent>\n"+
+		"<cffunction name=\"getList\" access=\"public\"></cffunction>\n"+
+		"<cffunction name=\"save\" access=\"public\"></cffunction>\n"+
+		"</cfcomponent>\n"), 0644))
+	a.NoError(os.WriteFile(page, []byte("<cfinvoke component=\"cfc.Members\" method=\"getList\">\n"+
+		"<cfquery name=\"q\">SELECT * FROM members WHERE id = #url.id#</cfquery>\n"), 0644))
+
+	a.NoError(parseFile(component))
+	a.NoError(parseFile(page))
+	reanalyze()
+
+	a.Equal(map[string]interface{}{"/cfc/Members.save": nil}, orphanSet())
+	a.Equal(1, sqlLintCt)
+
+	// The page now calls the other method and no longer has a query
+	a.NoError(os.WriteFile(page, []byte("<cfinvoke component=\"cfc.Members\" method=\"save\">\n"), 0644))
+
+	unindexFile(page)
+	a.NoError(parseFile(page))
+	reanalyze()
+
+	a.Equal(map[string]interface{}{"/cfc/Members.getList": nil}, orphanSet())
+	a.Equal(0, sqlLintCt)
+
+	// The reports only hold the latest results
+	report, err := os.ReadFile(orphanWriter.Name())
+	if a.NoError(err) {
+		a.Equal("Orphaned functions and their component\nComponent: /cfc/Members\n    getList\n", string(report))
+	}
+
+	report, err = os.ReadFile(sqlWriter.Name())
+	if a.NoError(err) {
+		a.Empty(report)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Keywords for an application or library in a workspace
+const (
+	KwName     = "name"
+	KwMappings = "mappings"
+)
+
+// Root of a tree of files, either the web root of an application or a shared component library
+type rootDef struct {
+	name      string            // Name qualifying the file and component names in a workspace
+	dir       string            // Fully qualified root directory
+	tagPath   []string          // Directories searched for components, relative to the root
+	variables map[string]string // Variable replacements for component names
+	mappings  map[string]string // Lower case component path prefixes and the directories they map to
+}
+
+// The roots being cross referenced, a single web root unless a workspace is configured
+var roots = make([]*rootDef, 0, 10)
+
+// A workspace with several applications and libraries is configured
+var workspaceMode bool = false
+
+// parseWorkspace Get the applications and libraries of a workspace from the configuration
+// returns false if the configuration is invalid
+func parseWorkspace(val interface{}) bool {
+	passed := true
+	workspaceMode = true
+	specs := val.(map[string]interface{})
+
+	for option, setting := range specs {
+		switch option {
+		case "apps", "libraries":
+			for _, entry := range setting.([]interface{}) {
+				root, err := parseRoot(entry.(map[string]interface{}))
+
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Invalid %s entry in %s: %s\n", option, KwWorkspace, err)
+					passed = false
+					continue
+				}
+
+				roots = append(roots, root)
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Invalid %s parameter '%s'\n", KwWorkspace, option)
+			passed = false
+		}
+	}
+
+	return passed
+}
+
+// parseRoot Get the settings of one application or library in a workspace
+func parseRoot(specs map[string]interface{}) (*rootDef, error) {
+	root := &rootDef{variables: make(map[string]string), mappings: make(map[string]string)}
+
+	for key, val := range specs {
+		switch strings.ToLower(key) {
+		case KwName:
+			root.name = val.(string)
+		case KwRoot:
+			root.dir = val.(string)
+		case KwPaths:
+			for _, path := range val.([]interface{}) {
+				root.tagPath = append(root.tagPath, cleanDirName(path.(string)))
+			}
+		case KwVars:
+			for name, replace := range val.(map[string]interface{}) {
+				root.variables["#"+strings.ToLower(strings.TrimSpace(name))+"#"] = replace.(string)
+			}
+		case KwMappings:
+			for prefix, dir := range val.(map[string]interface{}) {
+				root.mappings[strings.ToLower(cleanDirName(prefix))] = strings.TrimRight(dir.(string), `/\`)
+			}
+		default:
+			return nil, fmt.Errorf("unknown keyword '%s'", key)
+		}
+	}
+
+	if len(root.name) == 0 || len(root.dir) == 0 || strings.ContainsAny(root.name, ":/\\") {
+		return nil, fmt.Errorf("a %s (without slashes or colons) and a %s are required", KwName, KwRoot)
+	}
+
+	return root, nil
+}
+
+// setupRoots Finish the roots once the configuration is read.  The top level paths and vars apply to every
+// application and library in a workspace, without one the web root is the only root
+func setupRoots() {
+	if !workspaceMode {
+		roots = append(roots, &rootDef{dir: rootDir, tagPath: tagPath, variables: variables})
+		return
+	}
+
+	for _, root := range roots {
+		root.tagPath = append(root.tagPath, tagPath...)
+
+		for name, replace := range variables {
+			if _, found := root.variables[name]; !found {
+				root.variables[name] = replace
+			}
+		}
+	}
+}
+
+// enterRoot Make a root the current one for walking and parsing its files
+func enterRoot(root *rootDef) {
+	rootDir = root.dir
+	rootDirSize = len(root.dir)
+	tagPath = root.tagPath
+	variables = root.variables
+}
+
+// walkRoots Walk the tree of every root
+func walkRoots(walkFunc filepath.WalkFunc) error {
+	for _, root := range roots {
+		enterRoot(root)
+
+		if err := filepath.Walk(root.dir, walkFunc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rootOf Get the root holding a file, the one with the longest matching directory
+// returns nil when the file isn't in any of the roots
+func rootOf(fileName string) *rootDef {
+	var result *rootDef
+	size := -1
+	fileName = strings.ReplaceAll(fileName, `\`, "/")
+
+	for _, root := range roots {
+		dir := strings.TrimSuffix(strings.ReplaceAll(root.dir, `\`, "/"), "/")
+
+		// The directory has to match whole names, /web/app isn't the root of /web/app2
+		if len(dir) > size && len(fileName) >= len(dir) && strings.EqualFold(fileName[:len(dir)], dir) &&
+			(len(fileName) == len(dir) || fileName[len(dir)] == '/') {
+			result = root
+			size = len(dir)
+		}
+	}
+
+	return result
+}
+
+// relativeName Get the name of a file relative to its root, with forward slashes.  In a workspace the
+// name starts with the name of the application or library, i.e. members:/CFC/Members.cfc
+func relativeName(fileName string) string {
+	root := rootOf(fileName)
+
+	if root == nil {
+		return strings.ReplaceAll(fileName, `\`, "/")
+	}
+
+	relName := strings.ReplaceAll(fileName[len(strings.TrimRight(root.dir, `/\`)):], `\`, "/")
+
+	if workspaceMode {
+		relName = root.name + ":" + relName
+	}
+
+	return relName
+}
+
+// fullName Get the full name of a file from its name relative to its root
+func fullName(relName string) string {
+	if !workspaceMode {
+		return roots[0].dir + relName
+	}
+
+	colon := strings.IndexByte(relName, ':')
+
+	for _, root := range roots {
+		if colon > 0 && root.name == relName[:colon] {
+			return root.dir + relName[colon+1:]
+		}
+	}
+
+	return relName
+}
+
+// qualifier Get the start of the names in a root, i.e. members: (empty without a workspace)
+func qualifier(relName string) string {
+	if colon := strings.IndexByte(relName, ':'); workspaceMode && colon > 0 {
+		return relName[:colon+1]
+	}
+
+	return ""
+}
+
+// patchName Get the name of a file for a patch or output directory, which can't contain the colon
+func patchName(relName string) string {
+	if prefix := qualifier(relName); len(prefix) > 0 {
+		return "/" + prefix[:len(prefix)-1] + relName[len(prefix):]
+	}
+
+	return relName
+}
+
+// componentCandidates Get the keys a component name may have in the xref, in the order they are searched
+// compName: The component name as used in the call
+// caller: Relative name of the file making the call (empty when unknown)
+func componentCandidates(compName string, caller string) []string {
+	key := strings.ToLower(compName)
+	candidates := []string{key}
+	prefix := strings.ToLower(qualifier(caller))
+	root := roots[0]
+
+	if workspaceMode {
+		root = nil
+
+		for _, entry := range roots {
+			if strings.EqualFold(entry.name+":", prefix) {
+				root = entry
+			}
+		}
+
+		if root == nil {
+			return candidates
+		}
+
+		candidates = append(candidates, prefix+key)
+	}
+
+	// Mapped directories, with the longest prefixes first
+	mapped := make([]string, 0, len(root.mappings))
+	for mapping := range root.mappings {
+		mapped = append(mapped, mapping)
+	}
+
+	sort.Slice(mapped, func(i, j int) bool {
+		return len(mapped[i]) > len(mapped[j])
+	})
+
+	for _, mapping := range mapped {
+		if key == mapping || strings.HasPrefix(key, mapping+"/") {
+			candidates = append(candidates, strings.ToLower(relativeName(root.mappings[mapping])+key[len(mapping):]))
+			break
+		}
+	}
+
+	// The custom tag paths
+	for _, path := range root.tagPath {
+		candidates = append(candidates, prefix+strings.ToLower(path)+"/"+key)
+	}
+
+	return candidates
+}
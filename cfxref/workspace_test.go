@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// useWorkspace Make the roots of a workspace the ones being cross referenced for a test
+func useWorkspace(t *testing.T, workspace ...*rootDef) {
+	savedRoots, savedMode := roots, workspaceMode
+	t.Cleanup(func() {
+		roots, workspaceMode = savedRoots, savedMode
+	})
+
+	roots = workspace
+	workspaceMode = true
+}
+
+func TestRootOf(t *testing.T) {
+	a := assert.New(t)
+	app := &rootDef{name: "app", dir: "/web/app", mappings: map[string]string{}}
+	app2 := &rootDef{name: "app2", dir: "/web/app2/", mappings: map[string]string{}}
+	shared := &rootDef{name: "shared", dir: "/web/app/shared", mappings: map[string]string{}}
+	useWorkspace(t, app, app2, shared)
+
+	// The longest directory matching whole names wins
+	a.Same(app, rootOf("/web/app/index.cfm"))
+	a.Same(app, rootOf(`\web\App\index.cfm`))
+	a.Same(app2, rootOf("/web/app2/x.cfc"))
+	a.Same(shared, rootOf("/web/app/shared/Util.cfc"))
+	a.Same(app, rootOf("/web/app/sharedX/Util.cfc"))
+	a.Same(app, rootOf("/web/app"))
+
+	// A file outside the roots has none
+	a.Nil(rootOf("/web/other/x.cfc"))
+	a.Nil(rootOf("/web/ap"))
+
+	a.Equal("app2:/x.cfc", relativeName("/web/app2/x.cfc"))
+	a.Equal("shared:/Util.cfc", relativeName("/web/app/shared/Util.cfc"))
+	a.Equal("/web/other/x.cfc", relativeName(`\web\other\x.cfc`))
+}
+
+func TestComponentCandidates(t *testing.T) {
+	a := assert.New(t)
+	app := &rootDef{name: "app", dir: "/web/app", tagPath: []string{"/CFC"},
+		mappings: map[string]string{"/lib": "/web/shared/lib"}}
+	shared := &rootDef{name: "shared", dir: "/web/shared", mappings: map[string]string{}}
+	useWorkspace(t, app, shared)
+
+	a.Equal([]string{"/lib/util", "app:/lib/util", "shared:/lib/util", "app:/cfc//lib/util"},
+		componentCandidates("/lib/Util", "app:/index.cfm"))
+
+	// The caller's application isn't known
+	a.Equal([]string{"/lib/util"}, componentCandidates("/lib/Util", "other:/index.cfm"))
+}
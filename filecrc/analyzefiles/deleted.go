@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+
+	"dacdb.com/GoCode/filecrc/utils"
+)
+
+// How the files deleted since the baseline are written to the new baseline
+const (
+	DeletedDrop = "drop" // Leave the deleted files out
+	DeletedKeep = "keep" // Keep the deleted files with the deleted flag
+
+	maxMailedNames = 100 // Most deleted or moved files listed in the email, the log has them all
+)
+
+var (
+	deletedMode   string           = DeletedDrop               // Handling of the deleted files in the new baseline
+	deletedFiles  []string         = make([]string, 0)         // Names of the files deleted since the baseline
+	deletedBefore []utils.FileInfo = make([]utils.FileInfo, 0) // Files deleted before the baseline, kept with the flag
+)
+
+// findDeleted Report the baseline files the scan didn't find, they are still flagged as deleted from the load
+func findDeleted() {
+	deletedFiles = make([]string, 0)
+
+	for key, info := range fileMap {
+		if !info.IsDeleted() {
+			continue
+		}
+
+		deletedFiles = append(deletedFiles, info.GetName())
+
+		if deletedMode == DeletedDrop {
+			delete(fileMap, key)
+		}
+	}
+
+	sort.Strings(deletedFiles)
+	deletedCt = len(deletedFiles)
+
+	for _, name := range deletedFiles {
+		fmt.Fprintf(logWriter, "Deleted file: %s\n", name)
+	}
+
+	// The files deleted in an earlier scan are carried along, unless they came back
+	for _, info := range deletedBefore {
+		key := utils.FileKey(info.GetName())
+
+		if _, found := fileMap[key]; !found {
+			fileMap[key] = info
+		}
+	}
+}
+
+// deletedMessage List the deleted files for the email
+func deletedMessage() string {
+	if len(deletedFiles) == 0 {
+		return ""
+	}
+
+	message := "Deleted files:<br>"
+
+	for index, name := range deletedFiles {
+		if index == maxMailedNames {
+			message += fmt.Sprintf("and %d more, see the log<br>", len(deletedFiles)-maxMailedNames)
+			break
+		}
+
+		message += html.EscapeString(name) + "<br>"
+	}
+
+	return message + "<p>"
+}
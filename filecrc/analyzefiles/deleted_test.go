@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dacdb.com/GoCode/filecrc/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// deletedBaseline Get a baseline of the tree as loadFile leaves it, every file flagged deleted until the scan finds it
+func deletedBaseline(files map[string]utils.FileInfo) map[string]utils.FileInfo {
+	baseline := make(map[string]utils.FileInfo, len(files))
+
+	for key, info := range files {
+		info.ClearFlag()
+		info.SetDeleted()
+		baseline[key] = info
+	}
+
+	return baseline
+}
+
+func TestDeleted(t *testing.T) {
+	a := assert.New(t)
+
+	root := t.TempDir()
+	a.Nil(buildScanTree(root, 2, 5))
+
+	first, err := scanTree(root, 1, nil)
+	a.Nil(err)
+
+	gone := filepath.Join(root, "dir1", "file3.txt")
+	a.Nil(os.Remove(gone))
+
+	// Dropped from the new baseline
+	deletedMode = DeletedDrop
+	_, err = scanTree(root, 1, deletedBaseline(first.files))
+	a.Nil(err)
+	findDeleted()
+
+	a.Equal(1, deletedCt)
+	a.Equal([]string{gone}, deletedFiles)
+	a.Equal(9, len(fileMap))
+	a.Contains(deletedMessage(), "file3.txt")
+
+	// Kept with the flag
+	deletedMode = DeletedKeep
+	_, err = scanTree(root, 1, deletedBaseline(first.files))
+	a.Nil(err)
+	findDeleted()
+
+	a.Equal(1, deletedCt)
+	a.Equal(10, len(fileMap))
+	kept := fileMap[utils.FileKey(gone)]
+	a.True(kept.IsDeleted())
+	a.Equal("--D", kept.GetStatus())
+
+	// Deleted in an earlier scan, carried along but not reported again
+	deletedBefore = []utils.FileInfo{kept}
+	second := make(map[string]utils.FileInfo, len(fileMap))
+	for key, info := range fileMap {
+		if !info.IsDeleted() {
+			second[key] = info
+		}
+	}
+
+	_, err = scanTree(root, 1, deletedBaseline(second))
+	a.Nil(err)
+	findDeleted()
+
+	a.Equal(0, deletedCt)
+	a.Equal(10, len(fileMap))
+	a.Equal("", deletedMessage())
+
+	deletedMode = DeletedDrop
+	deletedBefore = make([]utils.FileInfo, 0)
+}
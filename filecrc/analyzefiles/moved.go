@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"sort"
+
+	"dacdb.com/GoCode/filecrc/utils"
+)
+
+var (
+	movedCt    int      = 0                 // Number of files moved or renamed
+	movedFiles []string = make([]string, 0) // Moves and renames as old -> new names
+)
+
+// fingerprint Key of the content of a file for pairing the deleted and added files
+func fingerprint(info utils.FileInfo) string {
+	return fmt.Sprintf("%s%s%d%s%t", info.GetDigest(), utils.FieldSep, info.GetSize(), utils.FieldSep, info.IsSampled())
+}
+
+// findMoves Pair the files added by the scan with the deleted files having the same content, they were moved or renamed.
+// Empty files have no fingerprint, and nothing is paired without the CRCs
+func findMoves() {
+	movedCt = 0
+	movedFiles = make([]string, 0)
+
+	if parmExcludeCRC {
+		return
+	}
+
+	// The deleted files by content, in name order so the pairs are the same on every run
+	deleted := make(map[string][]string)
+	added := make([]string, 0)
+
+	for key, info := range fileMap {
+		switch {
+		case info.GetSize() == 0:
+		case info.IsDeleted():
+			deleted[fingerprint(info)] = append(deleted[fingerprint(info)], key)
+		case info.IsAdded():
+			added = append(added, key)
+		}
+	}
+
+	if len(deleted) == 0 || len(added) == 0 {
+		return
+	}
+
+	for _, keys := range deleted {
+		sort.Strings(keys)
+	}
+
+	sort.Strings(added)
+
+	for _, key := range added {
+		data := fileMap[key]
+		candidates := deleted[fingerprint(data)]
+
+		if len(candidates) == 0 {
+			continue
+		}
+
+		// A file keeping its name was moved, prefer it to a rename
+		index := 0
+		for candidate, oldKey := range candidates {
+			if filepath.Base(oldKey) == filepath.Base(key) {
+				index = candidate
+				break
+			}
+		}
+
+		oldKey := candidates[index]
+		deleted[fingerprint(data)] = append(candidates[:index], candidates[index+1:]...)
+		movedFrom(key, data, fileMap[oldKey])
+		delete(fileMap, oldKey)
+	}
+
+	for _, move := range movedFiles {
+		fmt.Fprintf(logWriter, "Moved file: %s\n", move)
+	}
+}
+
+// movedFrom Record a file as moved rather than added, it is only suspicious or modified for the attributes changed by the move
+func movedFrom(key string, data utils.FileInfo, previous utils.FileInfo) {
+	newEntries--
+	movedCt++
+	movedFiles = append(movedFiles, previous.GetName()+" -> "+data.GetName())
+
+	if data.IsSuspicious() {
+		suspiciousCt--
+	}
+
+	data.ClearFlag()
+	data.SetMoved(previous.GetName())
+
+	if reason, suspicious := isSuspicious(data, previous); suspicious {
+		fmt.Fprintf(logWriter, "Suspicious file %s moved from %s: %s\n", data.GetName(), previous.GetName(), reason)
+		suspiciousCt++
+		data.SetSuspicious()
+	}
+
+	if !data.AttrsEqual(previous) {
+		mismatchedEntries++
+		data.SetMismatched()
+	}
+
+	fileMap[key] = data
+}
+
+// movedMessage List the moved files for the email
+func movedMessage() string {
+	if len(movedFiles) == 0 {
+		return ""
+	}
+
+	message := "Moved files:<br>"
+
+	for index, move := range movedFiles {
+		if index == maxMailedNames {
+			message += fmt.Sprintf("and %d more, see the log<br>", len(movedFiles)-maxMailedNames)
+			break
+		}
+
+		message += html.EscapeString(move) + "<br>"
+	}
+
+	return message + "<p>"
+}
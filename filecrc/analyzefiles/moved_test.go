@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"dacdb.com/GoCode/filecrc/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoved(t *testing.T) {
+	a := assert.New(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the permission bits are not kept on Windows")
+	}
+
+	root := t.TempDir()
+	a.Nil(buildScanTree(root, 2, 5))
+	a.Nil(os.WriteFile(filepath.Join(root, "dir0", "empty.txt"), nil, 0644))
+
+	first, err := scanTree(root, 1, nil)
+	a.Nil(err)
+
+	// A rename, a move to a new directory made executable on the way, and an empty file moved
+	renamed := filepath.Join(root, "dir0", "renamed.txt")
+	moved := filepath.Join(root, "dir2", "file2.txt")
+	a.Nil(os.Rename(filepath.Join(root, "dir0", "file1.txt"), renamed))
+	a.Nil(os.MkdirAll(filepath.Join(root, "dir2"), 0755))
+	a.Nil(os.Rename(filepath.Join(root, "dir1", "file2.txt"), moved))
+	a.Nil(os.Chmod(moved, 0755))
+	a.Nil(os.Rename(filepath.Join(root, "dir0", "empty.txt"), filepath.Join(root, "dir1", "empty.txt")))
+
+	deletedMode = DeletedDrop
+	_, err = scanTree(root, 1, deletedBaseline(first.files))
+	a.Nil(err)
+	findMoves()
+	findDeleted()
+
+	a.Equal(2, movedCt)
+	a.Equal([]string{filepath.Join(root, "dir0", "file1.txt") + " -> " + renamed, filepath.Join(root, "dir1", "file2.txt") + " -> " + moved},
+		movedFiles)
+	a.Contains(movedMessage(), "renamed.txt")
+
+	// The empty file has no fingerprint
+	a.Equal(1, newEntries)
+	a.Equal(1, deletedCt)
+	a.Equal(11, len(fileMap))
+
+	// Only the move changing the permissions is modified and suspicious
+	a.Equal(1, mismatchedEntries)
+	a.Equal(1, suspiciousCt)
+
+	info := fileMap[utils.FileKey(renamed)]
+	a.True(info.IsMoved())
+	a.Equal("--R", info.GetStatus())
+	a.Equal(filepath.Join(root, "dir0", "file1.txt"), info.GetMovedFrom())
+
+	info = fileMap[utils.FileKey(moved)]
+	a.Equal("SMR", info.GetStatus())
+}
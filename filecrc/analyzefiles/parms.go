@@ -36,6 +36,12 @@ const (
 	KwDebug       = "debug"     // Debug section
 	KwDebugStats  = "stats"     // Print debug stats
 	KwDebugMethod = "method"    // Tree walking method
+	KwHash        = "hash"      // Hash algorithm for the file contents
+	KwSampling    = "sampling"  // Sampling of very large files
+	KwSampleAbove = "above"     // Size above which files are sampled
+	KwSampleChunk = "chunk"     // Size of each sampled chunk
+	KwWorkers     = "workers"   // Number of workers hashing the files
+	KwDeleted     = "deleted"   // Handling of the deleted files in the new baseline
 
 	// Option flag specs
 	FlagVerifyConfig  = 'v' // Flag to indicate config verification only
@@ -47,17 +53,21 @@ const (
 )
 
 var (
-	parmVerifyConfig  bool   = false // Verify only
-	parmVerifyExclude bool   = false // Verify file selection criteria
-	parmConfigName    string = ""    // Configuration file name
-	parmBaseName      string = ""    // Base name to load for comparisons
-	parmAnalyzeOnly   bool   = false // Analyze only flag
-	parmExcludeCRC    bool   = false // Excluded CRC calculations
-	parmHostname      string = ""    // Host name
+	parmVerifyConfig  bool   = false           // Verify only
+	parmVerifyExclude bool   = false           // Verify file selection criteria
+	parmConfigName    string = ""              // Configuration file name
+	parmBaseName      string = ""              // Base name to load for comparisons
+	parmAnalyzeOnly   bool   = false           // Analyze only flag
+	parmExcludeCRC    bool   = false           // Excluded CRC calculations
+	parmHostname      string = ""              // Host name
+	hashAlgorithm     string = utils.HashCRC64 // Hash algorithm for the file contents
+	sampleAbove       int64  = 0               // Files larger than this are hashed by sampling (0 hashes every file in full)
+	sampleChunk       int64  = OneMB           // Size of the head, middle and tail chunks hashed when sampling
 )
 
 // Other constants
 const (
+	ToolVersion           = "2.0"          // Version recorded in the baseline header
 	DefaultOutputFileName = "fileinfo.txt" // Default name for the file inside the zip
 	AttachLogName         = "log"          // Logical name for attaching the log file
 	AttachZipName         = "zip"          // Logical name for attaching the zip file
@@ -168,7 +178,7 @@ func getConfig(configSpec string) error {
 				dirName := strings.TrimSuffix(strings.ReplaceAll(filename.(string), `\`, "/"), "/")
 
 				// Add to the list of root directories
-				rootDirs = append(rootDirs, strings.ToLower(dirName))
+				rootDirs = append(rootDirs, utils.FileKey(dirName))
 			}
 		case KwZipName:
 			inputZipFile = val.(string)
@@ -232,6 +242,49 @@ func getConfig(configSpec string) error {
 		case KwLogFile:
 			logFileName = val.(string)
 
+		case KwHash:
+			hashAlgorithm = strings.ToLower(val.(string))
+
+			if _, err := utils.NewHash(hashAlgorithm); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				success = false
+			}
+
+		case KwSampling:
+			samplingInfo := val.(map[string]interface{})
+			for name, sampling := range samplingInfo {
+				switch name {
+				case KwSampleAbove:
+					sampleAbove = int64(sampling.(float64))
+				case KwSampleChunk:
+					sampleChunk = int64(sampling.(float64))
+				default:
+					fmt.Fprintf(os.Stderr, "The sampling keyword '%s' is invalid\n", name)
+					success = false
+				}
+			}
+
+			if sampleChunk <= 0 || sampleAbove < 0 {
+				fmt.Fprintf(os.Stderr, "The sampling sizes must be positive\n")
+				success = false
+			}
+
+		case KwWorkers:
+			hashWorkers = int(val.(float64))
+
+			if hashWorkers < 0 {
+				fmt.Fprintf(os.Stderr, "The number of %s must be 0 (one per CPU) or more\n", KwWorkers)
+				success = false
+			}
+
+		case KwDeleted:
+			deletedMode = strings.ToLower(val.(string))
+
+			if deletedMode != DeletedDrop && deletedMode != DeletedKeep {
+				fmt.Fprintf(os.Stderr, "The %s setting must be %s or %s\n", KwDeleted, DeletedDrop, DeletedKeep)
+				success = false
+			}
+
 		case KwDebug:
 			debugInfo := val.(map[string]interface{})
 			for name, debug := range debugInfo {
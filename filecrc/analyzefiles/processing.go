@@ -51,15 +51,18 @@ var (
 	mismatchedEntries int                       = 0                                       // Number of entries that did not match
 	newEntries        int                       = 0                                       // Number of newly added entries
 	unchangedEntries  int                       = 0                                       // Number of entries that are the same
-	deletedCt         int                       = 0                                       // Number of deleted entries (calculated)
+	deletedCt         int                       = 0                                       // Number of deleted entries
 	fileMap           map[string]utils.FileInfo = make(map[string]utils.FileInfo, 100000) // Collection of CRC info for each file
 	totalFileSize     int64                     = 0                                       // Total size of all files read
 	maxFileSize       int64                     = 0                                       // Maximum file size read
+	sampledCt         int                       = 0                                       // Number of files hashed by sampling
+	scanTime          time.Time                                                           // Time the scan started
 )
 
 // initialize Do some initialization
 func run(args []string) int {
 	startTime := time.Now()
+	scanTime = startTime
 
 	// Initialize and check status
 	parseErrs := getParms(args)
@@ -114,9 +117,6 @@ func run(args []string) int {
 		fmt.Fprintln(logWriter, err)
 	}
 
-	// Compute number of files deleted
-	deletedCt = len(fileMap) - newEntries - unchangedEntries - mismatchedEntries
-
 	// Display some stats before the email so they're in the logfile
 	fmt.Fprintf(logWriter, "Processing completed\n")
 	fmt.Fprintf(logWriter, "Total files processed:        %s\n", utils.NiceInt(totalFiles))
@@ -124,11 +124,13 @@ func run(args []string) int {
 	fmt.Fprintf(logWriter, "Number of modified files:     %s\n", utils.NiceInt(mismatchedEntries))
 	fmt.Fprintf(logWriter, "Number of files added:        %s\n", utils.NiceInt(newEntries))
 	fmt.Fprintf(logWriter, "Number of files deleted:      %s\n", utils.NiceInt(deletedCt))
+	fmt.Fprintf(logWriter, "Number of files moved:        %s\n", utils.NiceInt(movedCt))
 
 	// Exclude stats if no CRC
 	if !parmExcludeCRC {
 		fmt.Fprintf(logWriter, "Total size of files read:     %s\n", utils.NiceInt64(totalFileSize))
 		fmt.Fprintf(logWriter, "Maximum file size read:       %s\n", utils.NiceInt64(maxFileSize))
+		fmt.Fprintf(logWriter, "Number of files sampled:      %s\n", utils.NiceInt(sampledCt))
 	} else {
 		fmt.Fprintf(logWriter, "Total size of files read:     %s\n", "No files were read")
 		fmt.Fprintf(logWriter, "Maximum file size read:       %s\n", "No files were read")
@@ -265,6 +267,8 @@ func process() (err error) {
 
 	if parmExcludeCRC {
 		fmt.Fprintf(logWriter, "No CRC will be computed or compared\n")
+	} else {
+		fmt.Fprintf(logWriter, "The file contents are hashed with %s\n", hashAlgorithm)
 	}
 
 	// If you are setup to compare fields, load the file
@@ -300,6 +304,44 @@ func process() (err error) {
 	}
 
 	// Walk the trees
+	if err = walkRoots(); err != nil {
+		return err
+	}
+
+	// The files of the baseline that were not found, unless they were moved
+	if compareFields {
+		findMoves()
+		findDeleted()
+	}
+
+	// See if you need to save the file
+	if parmVerifyExclude || parmAnalyzeOnly {
+		// Just verifying or analyzing dont save
+		err = nil
+	} else {
+		// Save the file
+		err = saveFile()
+	}
+
+	// Success
+	return err
+}
+
+// walkRoots Walk the root directories, handing the files to the workers to hash
+func walkRoots() (err error) {
+	startWorkers()
+
+	if workerCount() > 1 && !parmVerifyExclude {
+		fmt.Fprintf(logWriter, "The files are hashed by %d workers\n", workerCount())
+	}
+
+	// The files already queued are finished even when the walk fails
+	defer func() {
+		if hashErr := stopWorkers(); err == nil {
+			err = hashErr
+		}
+	}()
+
 	for _, RootDir := range rootDirs {
 		// Walk the requested tree
 		if walkFilepath {
@@ -323,17 +365,7 @@ func process() (err error) {
 		}
 	}
 
-	// See if you need to save the file
-	if parmVerifyExclude || parmAnalyzeOnly {
-		// Just verifying or analyzing dont save
-		err = nil
-	} else {
-		// Save the file
-		err = saveFile()
-	}
-
-	// Success
-	return err
+	return nil
 }
 
 // Walk the directory tree using filepath and process all files (not directories or links)
@@ -391,6 +423,14 @@ func processPath(path string, isDir bool, baseName string, skipDirReturn error)
 		return nil
 	}
 
+	// Hash the file, on one of the workers when there are several
+	return queueFile(path, originalPath)
+}
+
+// processFile Hash a file and merge it into the map, comparing it with the previous run
+// path: Path of the file with the slashes cleaned up
+// originalPath: Path as the walker found it
+func processFile(path string, originalPath string) error {
 	// Get the times and compute the CRC64 for the specified file
 	data, err := computeFileCRC64(path)
 
@@ -404,8 +444,12 @@ func processPath(path string, isDir bool, baseName string, skipDirReturn error)
 		return err
 	}
 
+	// The map and the counters are shared by the workers
+	mergeLock.Lock()
+	defer mergeLock.Unlock()
+
 	// If not only building, then lookup the info and compare
-	keyName := strings.ToLower(data.GetName())
+	keyName := utils.FileKey(data.GetName())
 
 	fileData, found := fileMap[keyName]
 
@@ -421,8 +465,19 @@ func processPath(path string, isDir bool, baseName string, skipDirReturn error)
 	if compareFields {
 		// if it's found, then do the compare
 		if found {
+			// Without the CRCs only the times, size and attributes are compared
+			equal := data.MetadataEqual(fileData)
+
+			if !parmExcludeCRC {
+				if !data.CRCComparable(fileData) {
+					fmt.Fprintf(logWriter, "Contents of %s could not be compared with the baseline, counted as changed\n", originalPath)
+				}
+
+				equal = data.IsEqual(fileData)
+			}
+
 			// Accumulate the number of changed records
-			if !data.IsEqual(fileData) {
+			if !equal {
 				data.SetMismatched()
 				mismatchedEntries++
 			} else {
@@ -442,6 +497,10 @@ func processPath(path string, isDir bool, baseName string, skipDirReturn error)
 	// Update sizes
 	totalFileSize = totalFileSize + data.GetSize()
 
+	if data.IsSampled() {
+		sampledCt++
+	}
+
 	if data.GetSize() > maxFileSize {
 		maxFileSize = data.GetSize()
 	}
@@ -463,12 +522,31 @@ func loadFile(fileData []byte) error {
 	scanner.Buffer(scanBuff, 500000)
 
 	// Read each line of the buffer
+	header := utils.BaselineHeader{}
+	checked := false
 	lineNo := 0
 	for scanner.Scan() {
 		// Process each line
 		lineNo++
 		currentLine := scanner.Text()
 
+		// The header comes before the records
+		if utils.IsHeaderLine(currentLine) {
+			if err := header.ParseHeaderLine(currentLine); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !checked {
+			if err := checkHeader(header); err != nil {
+				return err
+			}
+
+			checked = true
+		}
+
 		fileInfo := utils.FileInfo{}
 		err := fileInfo.ParseCRCLine(currentLine)
 
@@ -477,21 +555,76 @@ func loadFile(fileData []byte) error {
 		}
 
 		// Clear the flag
+		wasDeleted := fileInfo.IsDeleted()
 		fileInfo.ClearFlag()
 
-		// Add the entry to the map
-		fileMap[strings.ToLower(fileInfo.GetName())] = fileInfo
+		// Digests of different algorithms can't be compared
+		if fileInfo.GetAlgorithm() != hashAlgorithm && !parmExcludeCRC {
+			return fmt.Errorf("the baseline was hashed with %s but the configuration uses %s, start a new baseline to change the algorithm",
+				fileInfo.GetAlgorithm(), hashAlgorithm)
+		}
+
+		// A file deleted before the baseline is only carried along when the deleted files are kept
+		fileInfo.SetDeleted()
+
+		if wasDeleted {
+			if deletedMode == DeletedKeep {
+				deletedBefore = append(deletedBefore, fileInfo)
+			}
+
+			continue
+		}
+
+		// Add the entry to the map, it stays deleted until the scan finds the file
+		fileMap[utils.FileKey(fileInfo.GetName())] = fileInfo
+	}
+
+	// A baseline without files is checked all the same
+	if !checked {
+		return checkHeader(header)
 	}
 
 	// Return success
 	return nil
 }
 
+// checkHeader Check the baseline was taken the same way as this scan, the version 1 files have no header to check
+func checkHeader(header utils.BaselineHeader) error {
+	if header.GetVersion() < 2 {
+		fmt.Fprintf(logWriter, "The baseline has no header, it was written by an older version\n")
+		return nil
+	}
+
+	fmt.Fprintf(logWriter, "The baseline of %s was taken at %s by version %s\n",
+		header.GetHost(), header.GetScanTime().Format(time.RFC3339), header.GetToolVersion())
+
+	if !header.RootDirsMatch(rootDirs) {
+		return fmt.Errorf("the baseline was taken of %s but the configuration has %s, start a new baseline to change the root directories",
+			strings.Join(header.GetRootDirs(), ", "), strings.Join(rootDirs, ", "))
+	}
+
+	if header.GetAlgorithm() != hashAlgorithm && !parmExcludeCRC {
+		return fmt.Errorf("the baseline was hashed with %s but the configuration uses %s, start a new baseline to change the algorithm",
+			header.GetAlgorithm(), hashAlgorithm)
+	}
+
+	return nil
+}
+
 // saveFile Save the intrnal map to a file by creating a file in an encrypted zip
 func saveFile() error {
 	// Create a buffer and write each entry from the map
 	var buff bytes.Buffer
 
+	// The header describes the scan
+	header := utils.BaselineHeader{}
+	header.SetHost(parmHostname)
+	header.SetRootDirs(rootDirs)
+	header.SetAlgorithm(hashAlgorithm)
+	header.SetToolVersion(ToolVersion)
+	header.SetScanTime(scanTime)
+	buff.WriteString(header.BuildHeader())
+
 	var line string
 	for _, entry := range fileMap {
 		// Create the line for the file and write it into the buffer
@@ -536,8 +669,8 @@ func processEmail(err error) error {
 	}
 
 	// Build the email subject
-	emailSubject := fmt.Sprintf("%s scanned files: %d suspicious, %d total, %d added, %d modified %d deleted",
-		parmHostname, suspiciousCt, totalFiles, newEntries, mismatchedEntries, deletedCt)
+	emailSubject := fmt.Sprintf("%s scanned files: %d suspicious, %d total, %d added, %d modified %d deleted %d moved",
+		parmHostname, suspiciousCt, totalFiles, newEntries, mismatchedEntries, deletedCt, movedCt)
 	// setup the message
 	message := ""
 
@@ -551,6 +684,10 @@ func processEmail(err error) error {
 		message += "Processing completed <b>without errors</b><p>"
 	}
 
+	// List the deleted and moved files
+	message += deletedMessage()
+	message += movedMessage()
+
 	// List attached files
 	for _, files := range attachFiles {
 		message += "File " + files + " attached<p>"
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"time"
 
 	"dacdb.com/GoCode/filecrc/utils"
@@ -10,6 +11,9 @@ var (
 	suspiciousCt int = 0 // Number of suspicious files encountered
 )
 
+// Permission bits that widen the access to a file when they are added: execute, group and other write, setuid and setgid
+const escalatedPerms = 0111 | 0022 | 06000
+
 // isSuspicious Check to see if the data for the current record is suspicious
 // Uses information from the historical data and compares specific
 // values such as crc, file size and file date/time to deteremine if the
@@ -23,7 +27,10 @@ func isSuspicious(data utils.FileInfo, fileData utils.FileInfo) (string, bool) {
 	// Note: You CANNOT verify the modified date against the create date since windows retains the
 	// modified date when copying a file but sets the create date to the time of the copy
 	// so with a copied file the modified date is always less than the created date
-	if data.GetAccessed().Nanosecond() == 0 || data.GetCreated().Nanosecond() == 0 {
+	// The times can only be compared when the platform keeps both, many Linux file systems have no created time
+	if !data.GetTimeFields().Has(utils.TimeCreated | utils.TimeAccessed) {
+		// Nothing to compare
+	} else if data.GetAccessed().Nanosecond() == 0 || data.GetCreated().Nanosecond() == 0 {
 		access := time.Unix(data.GetAccessed().Unix(), 0)
 		create := time.Unix(data.GetCreated().Unix(), 0)
 
@@ -36,25 +43,48 @@ func isSuspicious(data utils.FileInfo, fileData utils.FileInfo) (string, bool) {
 		}
 	}
 
-	// CRC comparisons cannot be done when not generating CRC
-	if !parmExcludeCRC {
+	// CRC comparisons cannot be done when not generating CRC, or when one of the CRCs is sampled
+	if !parmExcludeCRC && data.CRCComparable(fileData) {
 		// Rule2: Only the size changed
-		if data.GetSize() != fileData.GetSize() && data.GetCRC() == fileData.GetCRC() && data.TimesEqual(fileData) {
+		if data.GetSize() != fileData.GetSize() && data.DigestEqual(fileData) && data.TimesEqual(fileData) {
 			violation = "Only the size has changed"
 		}
 
 		// Rule3: Only the CRC changed
-		if data.GetCRC() != fileData.GetCRC() && data.GetSize() == fileData.GetSize() && data.TimesEqual(fileData) {
+		if !data.DigestEqual(fileData) && data.GetSize() == fileData.GetSize() && data.TimesEqual(fileData) {
 			violation = "Only crc changed"
 		}
 
 		// Rule4: CRC and size changed but not modified date
-		if data.GetCRC() != fileData.GetCRC() && data.GetSize() != fileData.GetSize() &&
+		if !data.DigestEqual(fileData) && data.GetSize() != fileData.GetSize() &&
 			data.GetModified() == fileData.GetModified() {
 			violation = "CRC and size changed but not modified"
 		}
 	}
 
+	// Attribute comparisons need both records to have them
+	attrs, previous := data.GetAttrs(), fileData.GetAttrs()
+	common := attrs.GetValid() & previous.GetValid()
+
+	// Rule5: Permissions escalated, made executable, writable by others or setuid/setgid
+	if common.Has(utils.AttrPerm) && attrs.IsLink() == previous.IsLink() && attrs.GetPerm()&^previous.GetPerm()&escalatedPerms != 0 {
+		violation = fmt.Sprintf("Permissions escalated from %04o to %04o", previous.GetPerm(), attrs.GetPerm())
+	}
+
+	// Rule6: Owner or group changed
+	if common.Has(utils.AttrOwner) && (attrs.GetUid() != previous.GetUid() || attrs.GetGid() != previous.GetGid()) {
+		violation = fmt.Sprintf("Ownership changed from %d:%d to %d:%d", previous.GetUid(), previous.GetGid(), attrs.GetUid(), attrs.GetGid())
+	}
+
+	// Rule7: File replaced by a symbolic link, or a link pointed elsewhere
+	if common.Has(utils.AttrPerm) && attrs.IsLink() && attrs.GetTarget() != previous.GetTarget() {
+		if previous.IsLink() {
+			violation = fmt.Sprintf("Symbolic link changed from %s to %s", previous.GetTarget(), attrs.GetTarget())
+		} else {
+			violation = fmt.Sprintf("File replaced by a symbolic link to %s", attrs.GetTarget())
+		}
+	}
+
 	// Any violations are suspicious
 	if len(violation) > 0 {
 		suspicious = true
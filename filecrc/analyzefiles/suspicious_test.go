@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuspiciousAttrs(t *testing.T) {
+	a := assert.New(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the permission bits and symbolic links are not kept on Windows")
+	}
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "page.php")
+	a.Nil(os.WriteFile(fileName, []byte("<?php echo 'hello';"), 0644))
+	a.Nil(os.Chmod(fileName, 0644))
+
+	baseline, err := computeFileCRC64(fileName)
+	a.Nil(err)
+
+	_, suspicious := isSuspicious(baseline, baseline)
+	a.False(suspicious)
+
+	// Made executable
+	a.Nil(os.Chmod(fileName, 0755))
+	current, err := computeFileCRC64(fileName)
+	a.Nil(err)
+
+	reason, suspicious := isSuspicious(current, baseline)
+	a.True(suspicious)
+	a.Equal("Permissions escalated from 0644 to 0755", reason)
+	a.False(current.IsEqual(baseline))
+
+	// Removing permissions is not an escalation
+	a.Nil(os.Chmod(fileName, 0600))
+	current, err = computeFileCRC64(fileName)
+	a.Nil(err)
+
+	_, suspicious = isSuspicious(current, baseline)
+	a.False(suspicious)
+
+	// Replaced by a link to another file with the same content
+	otherName := filepath.Join(dir, "other.php")
+	a.Nil(os.WriteFile(otherName, []byte("<?php echo 'hello';"), 0644))
+	a.Nil(os.Remove(fileName))
+	a.Nil(os.Symlink(otherName, fileName))
+
+	current, err = computeFileCRC64(fileName)
+	a.Nil(err)
+	a.True(current.DigestEqual(baseline))
+
+	reason, suspicious = isSuspicious(current, baseline)
+	a.True(suspicious)
+	a.Equal("File replaced by a symbolic link to "+otherName, reason)
+
+	// A link with a missing target is still recorded
+	a.Nil(os.Remove(otherName))
+	current, err = computeFileCRC64(fileName)
+	a.Nil(err)
+	a.Equal(int64(0), current.GetSize())
+
+	_, suspicious = isSuspicious(current, baseline)
+	a.True(suspicious)
+}
@@ -1,19 +1,20 @@
 package main
 
 import (
-	"hash/crc64"
+	"errors"
 	"io"
 	"io/ioutil"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
-	"syscall"
-	"time"
 
 	"dacdb.com/GoCode/filecrc/utils"
 )
 
+// Size of the buffer the files are read through
+const hashBufferSize = 64 * 1024
+
 // computeFileCRC64 Compute the CRC64 for the file specified in the path
 // path: Full pathname of the file to compute the crc64 for
 // returns CRC64 for the file contents
@@ -21,38 +22,63 @@ func computeFileCRC64(path string) (utils.FileInfo, error) {
 	// Setup an empty response for errors
 	response := utils.FileInfo{}
 
-	// Get the file stat info
-	info, err := os.Stat(path)
+	// Get the attributes of the file itself, a symbolic link is not followed
+	attrs, linkInfo, err := utils.GetFileAttrs(path)
 
 	if err != nil {
 		return response, err
 	}
 
+	response.SetAttrs(attrs)
+
+	// Get the file stat info, a link with a missing target or to a directory is recorded with no content
+	info, err := os.Stat(path)
+	linkOnly := false
+
+	if attrs.IsLink() && (errors.Is(err, os.ErrNotExist) || (err == nil && info.IsDir())) {
+		info, linkOnly = linkInfo, true
+	} else if err != nil {
+		return response, err
+	}
+
 	// Get the date/time
-	fs := info.Sys().(*syscall.Win32FileAttributeData)
-	response.SetAccessed(time.Unix(0, fs.LastAccessTime.Nanoseconds()))
-	response.SetModified(time.Unix(0, fs.LastWriteTime.Nanoseconds()))
-	response.SetCreated(time.Unix(0, fs.CreationTime.Nanoseconds()))
+	times, err := utils.GetFileTimes(path, info)
+
+	if err != nil {
+		return response, err
+	}
+
+	response.SetTimes(times)
 
 	if parmExcludeCRC {
 		response.SetCRC(0)
+	} else if linkOnly {
+		hash, err := utils.NewHash(hashAlgorithm)
+
+		if err != nil {
+			return response, err
+		}
+
+		response.SetDigest(hashAlgorithm, hash.Sum(nil))
 	} else {
-		// Open the file
-		fileRdr, err := os.Open(path)
+		// Open the file without changing the accessed time where the platform allows it
+		fileRdr, err := utils.OpenQuietly(path)
 
 		if err != nil {
 			return response, err
 		}
 
-		// Read the file in
-		content := make([]byte, info.Size())
+		// Compute the digest, only sampling the very large files
+		sampled := sampleAbove > 0 && info.Size() > sampleAbove
+		sum, err := hashContent(fileRdr, info.Size(), sampled)
 
-		if _, err := io.ReadFull(fileRdr, content); err != nil {
+		if err != nil {
+			fileRdr.Close()
 			return response, err
 		}
 
-		// Compute the crc
-		response.SetCRC(crc64.Checksum(content, crc64.MakeTable(crc64.ECMA)))
+		response.SetDigest(hashAlgorithm, sum)
+		response.SetSampled(sampled)
 
 		// Cleanup
 		err = fileRdr.Close()
@@ -62,24 +88,56 @@ func computeFileCRC64(path string) (utils.FileInfo, error) {
 		}
 
 		// Reset the times to before reading it
-		err = os.Chtimes(path, response.GetAccessed(), response.GetModified())
+		err = utils.RestoreTimes(path, times)
 
 		if err != nil {
 			return response, err
 		}
-
-		// Help out the garbage collection
-		content = nil
 	}
 
 	// Set the name and size
 	response.SetName(path)
-	response.SetSize(info.Size())
+
+	if !linkOnly {
+		response.SetSize(info.Size())
+	}
 
 	// Return result
 	return response, nil
 }
 
+// hashContent Compute the digest of a file with the configured algorithm by streaming it through a fixed buffer
+// file: The open file
+// size: Size of the file
+// sampled: Only hash chunks at the head, middle and tail of the file
+// returns the sum of the content read
+func hashContent(file *os.File, size int64, sampled bool) ([]byte, error) {
+	hash, err := utils.NewHash(hashAlgorithm)
+
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, hashBufferSize)
+
+	if !sampled {
+		_, err := io.CopyBuffer(hash, file, buffer)
+		return hash.Sum(nil), err
+	}
+
+	for _, offset := range []int64{0, (size - sampleChunk) / 2, size - sampleChunk} {
+		if offset < 0 {
+			offset = 0
+		}
+
+		if _, err := io.CopyBuffer(hash, io.NewSectionReader(file, offset, sampleChunk), buffer); err != nil {
+			return nil, err
+		}
+	}
+
+	return hash.Sum(nil), nil
+}
+
 // buildFileName Builds a filename based on the suggested name.  If it exists, a numeric suffix is added until unique
 func buildFileName(fileName string, isTemp bool) (string, error) {
 	// Cleanup first
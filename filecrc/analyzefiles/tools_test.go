@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash/crc64"
 	"os"
 	"testing"
 
@@ -70,3 +73,43 @@ func TestCRC(t *testing.T) {
 
 	fmt.Printf("%#v\n", otherInfo)
 }
+
+func TestSampledCRC(t *testing.T) {
+	a := assert.New(t)
+
+	// A file of 10 chunks
+	content := bytes.Repeat([]byte("0123456789abcdef"), 640)
+	sampleChunk = int64(len(content) / 10)
+	fileName := "testfiles/sampled.test"
+	a.Nil(os.WriteFile(fileName, content, 0644))
+	defer os.Remove(fileName)
+
+	file, err := os.Open(fileName)
+	a.Nil(err)
+
+	// The full CRC streams the whole file
+	full, err := hashContent(file, int64(len(content)), false)
+	a.Nil(err)
+	a.Equal(crc64.Checksum(content, crc64.MakeTable(crc64.ECMA)), binary.BigEndian.Uint64(full))
+
+	sampled, err := hashContent(file, int64(len(content)), true)
+	a.Nil(err)
+	file.Close()
+
+	// Changes outside of the chunks are not seen, changes inside them are
+	content[len(content)/4] = 'x'
+	a.Nil(os.WriteFile(fileName, content, 0644))
+	file, _ = os.Open(fileName)
+	unchanged, err := hashContent(file, int64(len(content)), true)
+	a.Nil(err)
+	a.Equal(sampled, unchanged)
+
+	content[len(content)/2] = 'x'
+	a.Nil(os.WriteFile(fileName, content, 0644))
+	changed, err := hashContent(file, int64(len(content)), true)
+	a.Nil(err)
+	a.NotEqual(sampled, changed)
+	file.Close()
+
+	sampleChunk = OneMB
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+
+	"dacdb.com/GoCode/filecrc/utils"
 )
 
 // usage Display program usage
@@ -26,6 +28,7 @@ func usage() {
     "exclude"   : ["pattern1", "pattern2"],
     "sendmail"  : true,
     "logfile"   : "filecrc.log",
+    "hash"      : "blake2b",
     "email"     : {
         "server"  : "mail.server.net",
         "port"    : 587,
@@ -36,6 +39,12 @@ func usage() {
         "cc"      : [],
         "attach"  : ["log", "zip"]
     },
+	"workers"   : 4,
+	"deleted"   : "keep",
+	"sampling"  : {
+		"above"   : 1073741824,
+		"chunk"   : 1048576
+	},
 	"debug"    : {
 		"stats"   : true
 	}
@@ -58,4 +67,17 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "%s: Specifies the email a list of logical files to attach\n", KwEmailAttach)
 	fmt.Fprintf(os.Stderr, "    note: valid names are \"%s\", \"%s\"\n", AttachLogName, AttachZipName)
 	fmt.Fprintf(os.Stderr, "%s: Specifies an optional log output file (default: stdout)\n", KwLogFile)
+	fmt.Fprintf(os.Stderr, "%s: Specifies the hash algorithm for the file contents (default: %s)\n", KwHash, utils.HashCRC64)
+	fmt.Fprintf(os.Stderr, "    note: valid names are \"%s\", \"%s\", \"%s\", \"%s\", a baseline is only compared with the same algorithm\n",
+		utils.HashCRC64, utils.HashSHA256, utils.HashBLAKE2b, utils.HashXXHash)
+	fmt.Fprintf(os.Stderr, "%s: Specifies the number of workers hashing the files (default: 0, one per CPU)\n", KwWorkers)
+	fmt.Fprintf(os.Stderr, "    note: use 1 to hash the files one by one as the directories are walked\n")
+	fmt.Fprintf(os.Stderr, "%s: Specifies whether the deleted files are dropped from the new file or kept flagged as deleted (default: %s)\n",
+		KwDeleted, DeletedDrop)
+	fmt.Fprintf(os.Stderr, "    note: valid values are \"%s\", \"%s\", the deleted files are listed in the log and the email either way\n",
+		DeletedDrop, DeletedKeep)
+	fmt.Fprintf(os.Stderr, "%s: Specifies the optional sampling of very large files\n", KwSampling)
+	fmt.Fprintf(os.Stderr, "%s: Specifies the size in bytes above which only the head, middle and tail of a file are hashed\n", KwSampleAbove)
+	fmt.Fprintf(os.Stderr, "%s: Specifies the size in bytes of each sampled chunk (default: 1 MB)\n", KwSampleChunk)
+	fmt.Fprintf(os.Stderr, "    note: sampled CRCs are flagged in the file and are not compared with full CRCs\n")
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"runtime"
+	"sync"
+)
+
+// A file found by the walker, waiting to be hashed
+type walkedFile struct {
+	path         string // Path with the slashes cleaned up
+	originalPath string // Path as the walker found it, for the messages
+}
+
+// Workers hashing the files while the walker goes on, the results are merged into fileMap under mergeLock
+var (
+	hashWorkers int             = 0   // Number of workers from the config (0 for one per CPU)
+	walkedFiles chan walkedFile = nil // Files waiting for a worker, nil when hashing serially
+	workerGroup sync.WaitGroup        // Workers running
+	mergeLock   sync.Mutex            // Protects fileMap, the counters and workerErr
+	workerErr   error           = nil // First error a worker ran into, which stops the walk
+)
+
+// workerCount Get the number of workers to start
+func workerCount() int {
+	if hashWorkers > 0 {
+		return hashWorkers
+	}
+
+	return runtime.NumCPU()
+}
+
+// startWorkers Start the workers hashing the files, a single worker hashes the files in the walker itself
+func startWorkers() {
+	workerErr = nil
+	count := workerCount()
+
+	if count <= 1 {
+		walkedFiles = nil
+		return
+	}
+
+	// Enough room to keep the workers busy while the walker reads a directory
+	walkedFiles = make(chan walkedFile, count*16)
+
+	for index := 0; index < count; index++ {
+		workerGroup.Add(1)
+		go hashWorker()
+	}
+}
+
+// hashWorker Hash the files queued until the walk is over
+func hashWorker() {
+	defer workerGroup.Done()
+
+	for file := range walkedFiles {
+		if err := processFile(file.path, file.originalPath); err != nil {
+			mergeLock.Lock()
+			if workerErr == nil {
+				workerErr = err
+			}
+			mergeLock.Unlock()
+		}
+	}
+}
+
+// queueFile Hand a file to the workers, or hash it now when there are none
+// returns the first error of the workers so the walk stops as it would when hashing serially
+func queueFile(path string, originalPath string) error {
+	if walkedFiles == nil {
+		return processFile(path, originalPath)
+	}
+
+	mergeLock.Lock()
+	err := workerErr
+	mergeLock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	walkedFiles <- walkedFile{path: path, originalPath: originalPath}
+
+	return nil
+}
+
+// stopWorkers Wait for the workers to hash the files queued
+// returns the first error of the workers
+func stopWorkers() error {
+	if walkedFiles != nil {
+		close(walkedFiles)
+		workerGroup.Wait()
+		walkedFiles = nil
+	}
+
+	return workerErr
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"dacdb.com/GoCode/filecrc/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// Results of a scan, to compare the serial and parallel runs
+type scanResult struct {
+	files      map[string]utils.FileInfo
+	total      int
+	added      int
+	mismatched int
+	unchanged  int
+	suspicious int
+	totalSize  int64
+	maxSize    int64
+}
+
+// buildScanTree Create a tree of files of different sizes to scan
+func buildScanTree(root string, dirs int, filesPerDir int) error {
+	for dir := 0; dir < dirs; dir++ {
+		dirName := filepath.Join(root, fmt.Sprintf("dir%d", dir))
+
+		if err := os.MkdirAll(dirName, 0755); err != nil {
+			return err
+		}
+
+		for file := 0; file < filesPerDir; file++ {
+			content := strings.Repeat(fmt.Sprintf("%d-%d ", dir, file), (dir*filesPerDir+file)*50+1)
+
+			if err := os.WriteFile(filepath.Join(dirName, fmt.Sprintf("file%d.txt", file)), []byte(content), 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// scanTree Scan the root with a number of workers, against an optional baseline
+func scanTree(root string, workers int, baseline map[string]utils.FileInfo) (scanResult, error) {
+	hashWorkers = workers
+	rootDirs = []string{root}
+	compareFields = baseline != nil
+	totalFiles, newEntries, mismatchedEntries, unchangedEntries, suspiciousCt = 0, 0, 0, 0, 0
+	totalFileSize, maxFileSize, sampledCt = 0, 0, 0
+
+	fileMap = make(map[string]utils.FileInfo, 1000)
+	for key, info := range baseline {
+		fileMap[key] = info
+	}
+
+	err := walkRoots()
+
+	return scanResult{files: fileMap, total: totalFiles, added: newEntries, mismatched: mismatchedEntries,
+		unchanged: unchangedEntries, suspicious: suspiciousCt, totalSize: totalFileSize, maxSize: maxFileSize}, err
+}
+
+func TestWorkers(t *testing.T) {
+	a := assert.New(t)
+
+	root := t.TempDir()
+	a.Nil(buildScanTree(root, 10, 20))
+
+	serial, err := scanTree(root, 1, nil)
+	a.Nil(err)
+	a.Equal(200, serial.total)
+	a.Equal(200, serial.added)
+
+	parallel, err := scanTree(root, 8, nil)
+	a.Nil(err)
+	a.Equal(serial, parallel)
+
+	// Compare against the first run with a few files changed
+	baseline := serial.files
+	a.Nil(os.WriteFile(filepath.Join(root, "dir1", "file1.txt"), []byte("changed"), 0644))
+	a.Nil(os.WriteFile(filepath.Join(root, "dir9", "new.txt"), []byte("new"), 0644))
+
+	serial, err = scanTree(root, 1, baseline)
+	a.Nil(err)
+	a.Equal(1, serial.added)
+	a.Equal(1, serial.mismatched)
+
+	parallel, err = scanTree(root, 8, baseline)
+	a.Nil(err)
+	a.Equal(serial, parallel)
+
+	hashWorkers = 0
+}
+
+func TestExcludeCRC(t *testing.T) {
+	a := assert.New(t)
+	savedLog := logWriter
+	defer func() { logWriter, parmExcludeCRC = savedLog, false }()
+
+	root := t.TempDir()
+	a.Nil(buildScanTree(root, 2, 5))
+
+	logName := filepath.Join(t.TempDir(), "log.txt")
+	log, err := os.Create(logName)
+	if !a.Nil(err) {
+		return
+	}
+
+	defer log.Close()
+	logWriter = log
+
+	full, err := scanTree(root, 1, nil)
+	a.Nil(err)
+
+	// Without the CRCs the files only change with their times, size or attributes
+	parmExcludeCRC = true
+	a.Nil(os.WriteFile(filepath.Join(root, "dir1", "file1.txt"), []byte("changed"), 0644))
+
+	excluded, err := scanTree(root, 1, full.files)
+	a.Nil(err)
+	a.Equal(1, excluded.mismatched)
+	a.Equal(9, excluded.unchanged)
+
+	output, err := os.ReadFile(logName)
+	a.Nil(err)
+	a.NotContains(string(output), "could not be compared")
+
+	hashWorkers = 0
+}
+
+func benchmarkScan(b *testing.B, workers int) {
+	root := b.TempDir()
+
+	if err := buildScanTree(root, 20, 50); err != nil {
+		b.Fatal(err)
+	}
+
+	logWriter, _ = os.Open(os.DevNull)
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := scanTree(root, workers, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	hashWorkers = 0
+}
+
+func BenchmarkScanSerial(b *testing.B) {
+	benchmarkScan(b, 1)
+}
+
+func BenchmarkScanParallel(b *testing.B) {
+	benchmarkScan(b, runtime.NumCPU())
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"dacdb.com/GoCode/filecrc/utils"
 	"github.com/pborman/getopt/v2"
@@ -16,6 +17,8 @@ const (
 	flagSuspicious = 's' // Dump suspicious records
 	flagAdded      = 'a' // Dump added records
 	flagModified   = 'm' // Dump modified records
+	flagDeleted    = 'd' // Dump deleted records
+	flagMoved      = 'r' // Dump moved or renamed records
 	flagFilename   = 'f' // Specify the File name in the zip file
 	flagPassword   = 'p' // Specify the zip file password
 	flagNameRegex  = 'n' // Specify a name regular expression
@@ -25,6 +28,8 @@ var (
 	parmSuspicious  bool   = false // Indicator to dump suspicious records
 	parmAdded       bool   = false // Indicator to dump inserted records
 	parmModified    bool   = false // Indicator to dump modified records
+	parmDeleted     bool   = false // Indicator to dump deleted records
+	parmMoved       bool   = false // Indicator to dump moved records
 	parmFilename    string = ""    // File name in the zip file
 	parmZipPassword string = ""    // Optional zip file password
 	parmInputFile   string = ""    // Input file name
@@ -33,10 +38,14 @@ var (
 	fileData  []byte         = nil // Uncompressed content of the file from the zip
 	nameRegex *regexp.Regexp = nil // Compiled name regular expression, if specified
 
+	header utils.BaselineHeader // Header of the baseline, empty for the older files
+
 	totalCt      int = 0 // Total number of records processed
 	suspiciousCt int = 0 // Number of suspicious records
 	insertedCt   int = 0 // Number of records inserted
 	modifiedCt   int = 0 // Number of modified records
+	deletedCt    int = 0 // Number of deleted records
+	movedCt      int = 0 // Number of moved records
 	unchangedCt  int = 0 // Number of unchanged records
 	selectedCt   int = 0 // Number of records selected
 )
@@ -74,6 +83,8 @@ func getParms(args []string) error {
 	flagSet.Flag(&parmSuspicious, flagSuspicious, "Dump suspicious files")
 	flagSet.Flag(&parmAdded, flagAdded, "Dump added files")
 	flagSet.Flag(&parmModified, flagModified, "Dump modified files")
+	flagSet.Flag(&parmDeleted, flagDeleted, "Dump deleted files")
+	flagSet.Flag(&parmMoved, flagMoved, "Dump moved or renamed files")
 	flagSet.Flag(&parmFilename, flagFilename, "Specify the file name in the zip file")
 	flagSet.Flag(&parmZipPassword, flagPassword, "Specify the zip file password (optional)")
 	flagSet.Flag(&parmNameRegex, flagNameRegex, "Regular expression for searching for a name")
@@ -96,7 +107,7 @@ func getParms(args []string) error {
 
 	// Some checking
 	if len(parmNameRegex) > 0 {
-		if parmAdded || parmModified || parmSuspicious {
+		if parmAdded || parmModified || parmSuspicious || parmDeleted || parmMoved {
 			return fmt.Errorf("the name search is mutually exclusive with other parameters")
 		}
 
@@ -113,11 +124,13 @@ func getParms(args []string) error {
 // usage Display program usage
 func usage() {
 	fmt.Fprintf(os.Stderr, "Dump records from the CRC zip file based on types\n")
-	fmt.Fprintf(os.Stderr, "Usage: [[-%c] [-%c] [-%c]]|[-%c nameRegExp] [-%c zipPassword] [-%c filename] zipFileName\n",
-		flagSuspicious, flagAdded, flagModified, flagNameRegex, flagPassword, flagFilename)
+	fmt.Fprintf(os.Stderr, "Usage: [[-%c] [-%c] [-%c] [-%c] [-%c]]|[-%c nameRegExp] [-%c zipPassword] [-%c filename] zipFileName\n",
+		flagSuspicious, flagAdded, flagModified, flagDeleted, flagMoved, flagNameRegex, flagPassword, flagFilename)
 	fmt.Fprintf(os.Stderr, " %c: Dump info for suspicious files\n", flagSuspicious)
 	fmt.Fprintf(os.Stderr, " %c: Dump info for added files\n", flagAdded)
 	fmt.Fprintf(os.Stderr, " %c: Dump info for modified filed\n", flagModified)
+	fmt.Fprintf(os.Stderr, " %c: Dump info for deleted files kept in the file\n", flagDeleted)
+	fmt.Fprintf(os.Stderr, " %c: Dump info for moved or renamed files\n", flagMoved)
 	fmt.Fprintf(os.Stderr, " %c: Dump info for file names matching the specified regular expression\n", flagNameRegex)
 	fmt.Fprintf(os.Stderr, "     Note: This parameter is mutually exclusive with the other selection parameters\n")
 	fmt.Fprintf(os.Stderr, " %c fileName: Name of the file to dump in the zip (default: first file found)\n", flagFilename)
@@ -156,6 +169,16 @@ func process() int {
 		lineNo++
 		currentLine := scanner.Text()
 
+		// The header describes the scan
+		if utils.IsHeaderLine(currentLine) {
+			if err := header.ParseHeaderLine(currentLine); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				return 2
+			}
+
+			continue
+		}
+
 		fileInfo := utils.FileInfo{}
 		err := fileInfo.ParseCRCLine(currentLine)
 
@@ -183,8 +206,17 @@ func process() int {
 					modifiedCt++
 				}
 
+				if fileInfo.IsDeleted() {
+					deletedCt++
+				}
+
+				if fileInfo.IsMoved() {
+					movedCt++
+				}
+
 				// Check the record
-				if (parmSuspicious && fileInfo.IsSuspicious()) || (parmAdded && fileInfo.IsAdded()) || (parmModified && fileInfo.IsMismatched()) {
+				if (parmSuspicious && fileInfo.IsSuspicious()) || (parmAdded && fileInfo.IsAdded()) || (parmModified && fileInfo.IsMismatched()) ||
+					(parmDeleted && fileInfo.IsDeleted()) || (parmMoved && fileInfo.IsMoved()) {
 					fileInfo.Display(os.Stdout)
 					selectedCt++
 				}
@@ -223,11 +255,23 @@ func printStats() {
 		fmt.Fprintf(os.Stdout, "\n")
 	}
 
+	// Print the header of the newer files
+	if header.GetVersion() > 1 {
+		fmt.Fprintf(os.Stdout, "Baseline version:        %d\n", header.GetVersion())
+		fmt.Fprintf(os.Stdout, "Host scanned:            %s\n", header.GetHost())
+		fmt.Fprintf(os.Stdout, "Root directories:        %s\n", strings.Join(header.GetRootDirs(), ", "))
+		fmt.Fprintf(os.Stdout, "Hash algorithm:          %s\n", header.GetAlgorithm())
+		fmt.Fprintf(os.Stdout, "Scanned at:              %s by version %s\n",
+			header.GetScanTime().Format(time.RFC3339), header.GetToolVersion())
+	}
+
 	// Print the rest of the stats
 	fmt.Fprintf(os.Stdout, "Total records read:      %s\n", utils.NiceInt(totalCt))
 	fmt.Fprintf(os.Stdout, "Total records selected:  %s\n", utils.NiceInt(selectedCt))
 	fmt.Fprintf(os.Stdout, "Suspicious records read: %s\n", utils.NiceInt(suspiciousCt))
 	fmt.Fprintf(os.Stdout, "Inserted  records read:  %s\n", utils.NiceInt(insertedCt))
 	fmt.Fprintf(os.Stdout, "Modified records read:   %s\n", utils.NiceInt(modifiedCt))
+	fmt.Fprintf(os.Stdout, "Deleted records read:    %s\n", utils.NiceInt(deletedCt))
+	fmt.Fprintf(os.Stdout, "Moved records read:      %s\n", utils.NiceInt(movedCt))
 	fmt.Fprintf(os.Stdout, "Unchanged records read:  %s\n", utils.NiceInt(unchangedCt))
 }
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The baseline file starts with a header of #key=value lines describing the scan, followed by one record per file.
+// Version 1 files have no header and positional record fields, version 2 records have key=value fields after the name
+const (
+	BaselineVersion = 2 // Version of the baseline files written
+
+	headerPrefix    = "#"         // Prefix of the header lines
+	headerSep       = "="         // Separator between the key and the value
+	headerVersion   = "filecrc"   // Version of the format, always the first header line
+	headerHost      = "host"      // Host scanned
+	headerRootDir   = "rootdir"   // Root directory scanned, one line for each
+	headerAlgorithm = "algorithm" // Hash algorithm of the file contents
+	headerTool      = "tool"      // Version of the tool writing the file
+	headerScanTime  = "scantime"  // Time the scan started
+)
+
+// Metadata of a baseline file
+type BaselineHeader struct {
+	version     int       // Format version, 1 for the files without a header
+	host        string    // Host scanned
+	rootDirs    []string  // Root directories scanned
+	algorithm   string    // Hash algorithm of the file contents
+	toolVersion string    // Version of the tool writing the file
+	scanTime    time.Time // Time the scan started
+}
+
+func (header *BaselineHeader) GetVersion() int {
+	if header.version == 0 {
+		return 1
+	}
+
+	return header.version
+}
+
+func (header *BaselineHeader) GetHost() string {
+	return header.host
+}
+
+func (header *BaselineHeader) GetRootDirs() []string {
+	return header.rootDirs
+}
+
+func (header *BaselineHeader) GetAlgorithm() string {
+	return header.algorithm
+}
+
+func (header *BaselineHeader) GetToolVersion() string {
+	return header.toolVersion
+}
+
+func (header *BaselineHeader) GetScanTime() time.Time {
+	return header.scanTime
+}
+
+func (header *BaselineHeader) SetHost(value string) {
+	header.host = value
+}
+
+func (header *BaselineHeader) SetRootDirs(value []string) {
+	header.rootDirs = value
+}
+
+func (header *BaselineHeader) SetAlgorithm(value string) {
+	header.algorithm = value
+}
+
+func (header *BaselineHeader) SetToolVersion(value string) {
+	header.toolVersion = value
+}
+
+func (header *BaselineHeader) SetScanTime(value time.Time) {
+	header.scanTime = value
+}
+
+// BuildHeader Construct the header lines of the current version
+func (header *BaselineHeader) BuildHeader() string {
+	var lines strings.Builder
+
+	writeHeaderLine(&lines, headerVersion, strconv.Itoa(BaselineVersion))
+	writeHeaderLine(&lines, headerHost, header.host)
+
+	for _, dir := range header.rootDirs {
+		writeHeaderLine(&lines, headerRootDir, dir)
+	}
+
+	writeHeaderLine(&lines, headerAlgorithm, header.algorithm)
+	writeHeaderLine(&lines, headerTool, header.toolVersion)
+	writeHeaderLine(&lines, headerScanTime, header.scanTime.Format(time.RFC3339Nano))
+
+	return lines.String()
+}
+
+// writeHeaderLine Write a header line, with the value escaped like the record names
+func writeHeaderLine(lines *strings.Builder, key string, value string) {
+	fmt.Fprintf(lines, "%s%s%s%s\n", headerPrefix, key, headerSep, EscapeField(value))
+}
+
+// IsHeaderLine Check if a line of the file is a header line rather than a record
+func IsHeaderLine(line string) bool {
+	return strings.HasPrefix(line, headerPrefix)
+}
+
+// ParseHeaderLine Parse a header line into the header, keys from later versions are ignored
+func (header *BaselineHeader) ParseHeaderLine(line string) error {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, headerPrefix), "\n")
+	key, value, found := strings.Cut(line, headerSep)
+
+	if !found {
+		return fmt.Errorf("the header line '%s' is invalid", line)
+	}
+
+	value, err := UnescapeField(value)
+
+	if err != nil {
+		return fmt.Errorf("the header line '%s' is invalid: %s", line, err)
+	}
+
+	switch key {
+	case headerVersion:
+		header.version, err = strconv.Atoi(value)
+	case headerHost:
+		header.host = value
+	case headerRootDir:
+		header.rootDirs = append(header.rootDirs, value)
+	case headerAlgorithm:
+		header.algorithm = value
+	case headerTool:
+		header.toolVersion = value
+	case headerScanTime:
+		header.scanTime, err = time.Parse(time.RFC3339Nano, value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("the header line '%s' is invalid: %s", line, err)
+	}
+
+	return nil
+}
+
+// RootDirsMatch Check if the baseline was taken of the same root directories, in any order
+func (header *BaselineHeader) RootDirsMatch(dirs []string) bool {
+	return strings.Join(cleanRootDirs(header.rootDirs), FieldSep) == strings.Join(cleanRootDirs(dirs), FieldSep)
+}
+
+// cleanRootDirs Normalize the root directories for comparing them, the case only matters where the file names are case sensitive
+func cleanRootDirs(dirs []string) []string {
+	cleaned := make([]string, 0, len(dirs))
+
+	for _, dir := range dirs {
+		dir = FileKey(strings.ReplaceAll(dir, `\`, "/"))
+
+		if len(dir) > 1 {
+			dir = strings.TrimSuffix(dir, "/")
+		}
+
+		cleaned = append(cleaned, dir)
+	}
+
+	sort.Strings(cleaned)
+
+	return cleaned
+}
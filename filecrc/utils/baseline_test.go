@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bufio"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaselineHeader(t *testing.T) {
+	a := assert.New(t)
+
+	header := BaselineHeader{}
+	a.Equal(1, header.GetVersion())
+
+	header.SetHost("My server")
+	header.SetRootDirs([]string{`c:\Dir1\`, "c:/Dir2"})
+	header.SetAlgorithm(HashSHA256)
+	header.SetToolVersion("2.0")
+	header.SetScanTime(time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC))
+
+	// A baseline with the header and a record
+	info := FileInfo{}
+	info.name = "c:/Dir1/a.txt"
+	info.times = TimeModified
+	info.SetDigest(HashSHA256, make([]byte, 32))
+	text := header.BuildHeader() + info.BuildCRCLine()
+
+	header2 := BaselineHeader{}
+	records := 0
+	scanner := bufio.NewScanner(strings.NewReader(text))
+
+	for scanner.Scan() {
+		if IsHeaderLine(scanner.Text()) {
+			a.Nil(header2.ParseHeaderLine(scanner.Text()))
+			continue
+		}
+
+		info2 := FileInfo{}
+		a.Nil(info2.ParseCRCLine(scanner.Text()))
+		a.True(info.IsEqual(info2))
+		records++
+	}
+
+	a.Equal(1, records)
+	a.Equal(BaselineVersion, header2.GetVersion())
+	a.Equal(header.GetHost(), header2.GetHost())
+	a.Equal(header.GetAlgorithm(), header2.GetAlgorithm())
+	a.Equal(header.GetToolVersion(), header2.GetToolVersion())
+	a.True(header.GetScanTime().Equal(header2.GetScanTime()))
+
+	// The root directories match in any order and slashes, the case only where file names ignore it
+	a.True(header2.RootDirsMatch([]string{"c:/Dir2", `c:\Dir1`}))
+	a.Equal(runtime.GOOS == "windows" || runtime.GOOS == "darwin", header2.RootDirsMatch([]string{"C:/dir2", "c:/dir1"}))
+	a.False(header2.RootDirsMatch([]string{"c:/Dir1"}))
+	a.False(header2.RootDirsMatch([]string{"c:/Dir1", "c:/Dir3"}))
+
+	// Keys of later versions are ignored, a line without a key is not
+	a.Nil(header2.ParseHeaderLine("#future=value"))
+	a.NotNil(header2.ParseHeaderLine("#nokey"))
+	a.NotNil(header2.ParseHeaderLine("#scantime=yesterday"))
+}
+
+func TestRecordVersions(t *testing.T) {
+	a := assert.New(t)
+
+	// The same file in both versions
+	v1 := FileInfo{}
+	a.Nil(v1.ParseCRCLine("-M-:rec1|2021-01-02T03:04:05Z|2021-01-02T03:04:06Z|2021-01-02T03:04:07Z|10|12345|2021-01-02T03:04:08Z|CAMH|S"))
+
+	v2 := FileInfo{}
+	a.Nil(v2.ParseCRCLine("-M-:rec1|size=10|hash=12345|created=2021-01-02T03:04:05Z|accessed=2021-01-02T03:04:06Z|" +
+		"modified=2021-01-02T03:04:07Z|changed=2021-01-02T03:04:08Z|times=CAMH|sampled=S|future=value"))
+	a.Equal(v1, v2)
+	a.True(v2.IsMismatched())
+
+	// Version 2 lines need the fields
+	a.NotNil(v2.ParseCRCLine("---:rec1|size=10|hash=12345"))
+	a.NotNil(v2.ParseCRCLine("---:rec1|size=10|hash"))
+	a.NotNil(v2.ParseCRCLine("--"))
+}
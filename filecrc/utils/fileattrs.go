@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// AttrFields A set of file attributes, used to record which of them the platform keeps
+type AttrFields byte
+
+const (
+	AttrPerm  AttrFields = 0x01 // Permission bits and the symbolic link target
+	AttrOwner AttrFields = 0x02 // Owner and group ids
+	AttrInode AttrFields = 0x04 // Inode, device and link count
+
+	permSetuid = 04000 // Unix set user id bit
+	permSetgid = 02000 // Unix set group id bit
+	permSticky = 01000 // Unix sticky bit
+)
+
+// Attributes of a file other than its times, size and content
+type FileAttrs struct {
+	perm   uint32     // Unix permission bits, with the setuid, setgid and sticky bits
+	uid    uint32     // Owner id
+	gid    uint32     // Group id
+	inode  uint64     // Inode number
+	device uint64     // Device holding the inode
+	links  uint64     // Number of hard links
+	target string     // Target of a symbolic link, empty for the other files
+	valid  AttrFields // The attributes that are meaningful on this platform
+}
+
+func (attrs *FileAttrs) GetPerm() uint32 {
+	return attrs.perm
+}
+
+func (attrs *FileAttrs) GetUid() uint32 {
+	return attrs.uid
+}
+
+func (attrs *FileAttrs) GetGid() uint32 {
+	return attrs.gid
+}
+
+func (attrs *FileAttrs) GetInode() uint64 {
+	return attrs.inode
+}
+
+func (attrs *FileAttrs) GetDevice() uint64 {
+	return attrs.device
+}
+
+func (attrs *FileAttrs) GetLinks() uint64 {
+	return attrs.links
+}
+
+func (attrs *FileAttrs) GetTarget() string {
+	return attrs.target
+}
+
+func (attrs *FileAttrs) GetValid() AttrFields {
+	return attrs.valid
+}
+
+// IsLink Check if the file is a symbolic link
+func (attrs *FileAttrs) IsLink() bool {
+	return len(attrs.target) > 0
+}
+
+// Has Check if the attributes are meaningful
+func (fields AttrFields) Has(attrs AttrFields) bool {
+	return fields&attrs == attrs
+}
+
+// GetFileAttrs Get the attributes of a file without following a symbolic link
+// path: Full path name of the file
+// returns the attributes and the stat info of the file itself
+func GetFileAttrs(path string) (FileAttrs, os.FileInfo, error) {
+	attrs := FileAttrs{}
+	info, err := os.Lstat(path)
+
+	if err != nil {
+		return attrs, nil, err
+	}
+
+	attrs.perm = unixPerm(info.Mode())
+	attrs.valid = AttrPerm
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		if attrs.target, err = os.Readlink(path); err != nil {
+			return attrs, nil, err
+		}
+	}
+
+	systemAttrs(info, &attrs)
+
+	return attrs, info, nil
+}
+
+// unixPerm Get the Unix permission bits of a mode
+func unixPerm(mode os.FileMode) uint32 {
+	perm := uint32(mode.Perm())
+
+	if mode&os.ModeSetuid != 0 {
+		perm |= permSetuid
+	}
+
+	if mode&os.ModeSetgid != 0 {
+		perm |= permSetgid
+	}
+
+	if mode&os.ModeSticky != 0 {
+		perm |= permSticky
+	}
+
+	return perm
+}
+
+// String Describe the attributes that are meaningful
+func (attrs FileAttrs) String() string {
+	text := ""
+
+	if attrs.valid.Has(AttrPerm) {
+		text += fmt.Sprintf(", Mode: %04o", attrs.perm)
+	}
+
+	if attrs.valid.Has(AttrOwner) {
+		text += fmt.Sprintf(", Owner: %d:%d", attrs.uid, attrs.gid)
+	}
+
+	if attrs.valid.Has(AttrInode) {
+		text += fmt.Sprintf(", Inode: %d/%d, Links: %d", attrs.device, attrs.inode, attrs.links)
+	}
+
+	if attrs.IsLink() {
+		text += fmt.Sprintf(", Target: %s", attrs.target)
+	}
+
+	return text
+}
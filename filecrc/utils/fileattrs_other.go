@@ -0,0 +1,11 @@
+//go:build !unix
+
+package utils
+
+import (
+	"os"
+)
+
+// systemAttrs Does nothing, only the permission bits are portable
+func systemAttrs(info os.FileInfo, attrs *FileAttrs) {
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAttrs(t *testing.T) {
+	a := assert.New(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "script.sh")
+	linkName := filepath.Join(dir, "link.sh")
+	a.Nil(os.WriteFile(fileName, []byte("echo hello\n"), 0644))
+	a.Nil(os.Chmod(fileName, 0644))
+	a.Nil(os.Symlink(fileName, linkName))
+
+	attrs, _, err := GetFileAttrs(fileName)
+	a.Nil(err)
+	a.Equal(uint32(0644), attrs.GetPerm())
+	a.False(attrs.IsLink())
+	a.True(attrs.GetValid().Has(AttrPerm | AttrOwner | AttrInode))
+	a.Equal(uint64(1), attrs.GetLinks())
+	a.Equal(uint32(os.Getuid()), attrs.GetUid())
+
+	linkAttrs, info, err := GetFileAttrs(linkName)
+	a.Nil(err)
+	a.True(linkAttrs.IsLink())
+	a.Equal(fileName, linkAttrs.GetTarget())
+	a.True(info.Mode()&os.ModeSymlink != 0)
+
+	// The attributes round trip through the records
+	for _, value := range []FileAttrs{attrs, linkAttrs} {
+		info := FileInfo{}
+		info.name = "script.sh"
+		info.times = TimeModified
+		info.SetAttrs(value)
+
+		info2 := FileInfo{}
+		a.Nil(info2.ParseCRCLine(info.BuildCRCLine()))
+		a.Equal(value, info2.GetAttrs())
+		a.True(info.IsEqual(info2))
+	}
+
+	// Setuid is kept with the permission bits
+	a.Nil(os.Chmod(fileName, 0755|os.ModeSetuid))
+	changed, _, err := GetFileAttrs(fileName)
+	a.Nil(err)
+	a.Equal(uint32(04755), changed.GetPerm())
+
+	// A permission change is a modification, the inode alone is not
+	before, after := FileInfo{}, FileInfo{}
+	before.SetAttrs(attrs)
+	after.SetAttrs(changed)
+	a.False(before.AttrsEqual(after))
+
+	changed = attrs
+	changed.inode++
+	after.SetAttrs(changed)
+	a.True(before.AttrsEqual(after))
+
+	// Records from the platforms without the attributes compare equal
+	after.SetAttrs(FileAttrs{})
+	a.True(before.AttrsEqual(after))
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// systemAttrs Get the ownership and the inode of a file from its stat info
+func systemAttrs(info os.FileInfo, attrs *FileAttrs) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+
+	if !ok {
+		return
+	}
+
+	attrs.uid = stat.Uid
+	attrs.gid = stat.Gid
+	attrs.inode = uint64(stat.Ino)
+	attrs.device = uint64(stat.Dev)
+	attrs.links = uint64(stat.Nlink)
+	attrs.valid |= AttrOwner | AttrInode
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"encoding/hex"
 	"fmt"
 	"os"
 	"strconv"
@@ -15,6 +16,8 @@ const (
 	flagSuspicious byte = 0x01 // Flag to indicate the record is suspicious
 	flagMismatched byte = 0x02 // Flag to indicate the record has changed
 	flagInserted   byte = 0x04 // Flag to indicate the record is new
+	flagDeleted    byte = 0x08 // Flag to indicate the file was not found by the scan
+	flagMoved      byte = 0x10 // Flag to indicate the file was moved or renamed from a deleted one
 
 	prefixSize     = 3   // Size of the file status prefix excluding the colon
 	prefixChar     = ':' // Prefix separator
@@ -22,17 +25,51 @@ const (
 	codeSusicious  = "S" // Code indicating the record is suspicious
 	codeMismatched = "M" // Code to indicate the record is modified (mismatched)
 	codeInserted   = "N" // Code to indicate the record is new
+	codeDeleted    = "D" // Code to indicate the file was deleted, in the place of the new code
+	codeMoved      = "R" // Code to indicate the file was moved or renamed, in the place of the new code
+	codeSampled    = "S" // Code to indicate the CRC is of sampled chunks of the file
+
+	// Keys of the version 2 record fields, the keys a version doesn't know are ignored
+	fieldSep      = "="        // Separator between the key and the value of a field
+	fieldCreated  = "created"  // Created time
+	fieldAccessed = "accessed" // Last accessed time
+	fieldModified = "modified" // Last modified time
+	fieldChanged  = "changed"  // Status change time
+	fieldTimes    = "times"    // Codes of the meaningful times
+	fieldSize     = "size"     // File size
+	fieldHash     = "hash"     // CRC64 or digest of the content
+	fieldSampled  = "sampled"  // Code for how the content was hashed
+	fieldPerm     = "perm"     // Unix permission bits in octal
+	fieldUid      = "uid"      // Owner id
+	fieldGid      = "gid"      // Group id
+	fieldInode    = "inode"    // Inode number
+	fieldDevice   = "device"   // Device holding the inode
+	fieldLinks    = "links"    // Number of hard links
+	fieldTarget   = "target"   // Target of a symbolic link
+	fieldFrom     = "from"     // Previous name of a moved file
+
+	escapeChar = '%' // Start of an escaped byte, followed by two hex digits
 )
 
+// Fields every version 2 record must have
+var requiredFields = []string{fieldCreated, fieldAccessed, fieldModified, fieldSize, fieldHash, fieldTimes}
+
 // File Info
 type FileInfo struct {
-	name     string    // Name of the file
-	modified time.Time // Last modified date
-	accessed time.Time // Last accessed time
-	created  time.Time // Created time
-	size     int64     // File size
-	crc      uint64    // Computed CRC
-	flag     byte      // Indicator flags
+	name      string     // Name of the file
+	modified  time.Time  // Last modified date
+	accessed  time.Time  // Last accessed time
+	created   time.Time  // Created time
+	changed   time.Time  // Status change time
+	times     TimeFields // Times that are meaningful on the platform that recorded them
+	size      int64      // File size
+	crc       uint64     // Computed CRC
+	digest    string     // Hex digest when the algorithm isn't CRC64
+	algorithm string     // Hash algorithm of the digest, empty for CRC64
+	sampled   bool       // The CRC is of the head, middle and tail of the file rather than all of it
+	attrs     FileAttrs  // Permissions, ownership, inode and link target
+	movedFrom string     // Previous name of a moved or renamed file
+	flag      byte       // Indicator flags
 }
 
 func (info *FileInfo) GetName() string {
@@ -50,6 +87,14 @@ func (info *FileInfo) GetCreated() time.Time {
 	return info.created
 }
 
+func (info *FileInfo) GetChanged() time.Time {
+	return info.changed
+}
+
+func (info *FileInfo) GetTimeFields() TimeFields {
+	return info.times
+}
+
 func (info *FileInfo) GetSize() int64 {
 	return info.size
 }
@@ -58,6 +103,31 @@ func (info *FileInfo) GetCRC() uint64 {
 	return info.crc
 }
 
+func (info *FileInfo) GetAlgorithm() string {
+	if len(info.algorithm) == 0 {
+		return HashCRC64
+	}
+
+	return info.algorithm
+}
+
+// GetDigest Get the digest as it is written in the record
+func (info *FileInfo) GetDigest() string {
+	return formatDigest(info.algorithm, info.crc, info.digest)
+}
+
+func (info *FileInfo) GetAttrs() FileAttrs {
+	return info.attrs
+}
+
+func (info *FileInfo) GetMovedFrom() string {
+	return info.movedFrom
+}
+
+func (info *FileInfo) IsSampled() bool {
+	return info.sampled
+}
+
 func (info *FileInfo) GetFlag() byte {
 	return info.flag
 }
@@ -78,10 +148,45 @@ func (info *FileInfo) SetCreated(value time.Time) {
 	info.created = value
 }
 
+func (info *FileInfo) SetChanged(value time.Time) {
+	info.changed = value
+}
+
+// SetTimes Set all the times along with the ones that are meaningful
+func (info *FileInfo) SetTimes(times FileTimes) {
+	info.created = times.created
+	info.accessed = times.accessed
+	info.modified = times.modified
+	info.changed = times.changed
+	info.times = times.valid
+}
+
 func (info *FileInfo) SetCRC(value uint64) {
 	info.crc = value
 }
 
+// SetDigest Set the digest from the sum of a hash
+func (info *FileInfo) SetDigest(algorithm string, sum []byte) {
+	if algorithm == HashCRC64 {
+		info.algorithm = ""
+		info.crc = crcFromSum(sum)
+		info.digest = ""
+		return
+	}
+
+	info.algorithm = algorithm
+	info.crc = 0
+	info.digest = hex.EncodeToString(sum)
+}
+
+func (info *FileInfo) SetAttrs(value FileAttrs) {
+	info.attrs = value
+}
+
+func (info *FileInfo) SetSampled(value bool) {
+	info.sampled = value
+}
+
 func (info *FileInfo) SetSize(value int64) {
 	info.size = value
 }
@@ -90,30 +195,134 @@ func (info *FileInfo) SetFlag(value byte) {
 	info.flag = value
 }
 
+// IsEqual Compare the records, contents that were hashed differently can't be shown to be the same so they are not equal
 func (info *FileInfo) IsEqual(other FileInfo) bool {
-	return info.TimesEqual(other) && info.size == other.size && info.crc == other.crc
+	return info.MetadataEqual(other) && info.CRCComparable(other) && info.DigestEqual(other)
+}
+
+// MetadataEqual Compare the times, size and attributes of the records without their contents
+func (info *FileInfo) MetadataEqual(other FileInfo) bool {
+	return info.TimesEqual(other) && info.size == other.size && info.AttrsEqual(other)
+}
+
+// AttrsEqual Compare the permissions, ownership and link target that are meaningful in both records.  The inode
+// is not compared, editors replace the files they save
+func (info *FileInfo) AttrsEqual(other FileInfo) bool {
+	common := info.attrs.valid & other.attrs.valid
+
+	if common.Has(AttrPerm) && (info.attrs.perm != other.attrs.perm || info.attrs.target != other.attrs.target) {
+		return false
+	}
+
+	return !common.Has(AttrOwner) || (info.attrs.uid == other.attrs.uid && info.attrs.gid == other.attrs.gid)
+}
+
+// DigestEqual Check if the contents hashed the same
+func (info *FileInfo) DigestEqual(other FileInfo) bool {
+	return info.algorithm == other.algorithm && info.crc == other.crc && info.digest == other.digest
+}
+
+// CRCComparable Check if the CRCs were computed the same way, a sampled CRC can't be compared with a full one
+// and digests of different algorithms can't be compared at all
+func (info *FileInfo) CRCComparable(other FileInfo) bool {
+	return info.sampled == other.sampled && info.algorithm == other.algorithm
 }
 
+// TimesEqual Compare the times that are meaningful in both records, so a baseline from another platform
+// or an older version only compares the times they have in common
 func (info *FileInfo) TimesEqual(other FileInfo) bool {
-	return info.created == other.created && info.accessed == other.accessed && info.modified == other.modified
+	common := info.times & other.times
+
+	return common != 0 &&
+		timeEqual(common, TimeCreated, info.created, other.created) &&
+		timeEqual(common, TimeAccessed, info.accessed, other.accessed) &&
+		timeEqual(common, TimeModified, info.modified, other.modified) &&
+		timeEqual(common, TimeChanged, info.changed, other.changed)
 }
 
-// buildCRCLine  Construct a CRC summary line with a separator
+// timeEqual Compare a time when it's one of the common times
+func timeEqual(common TimeFields, field TimeFields, value time.Time, other time.Time) bool {
+	return !common.Has(field) || value.Equal(other)
+}
+
+// buildCRCLine  Construct a version 2 CRC summary line, the name followed by key=value fields with a separator
 func (info *FileInfo) BuildCRCLine() string {
-	return fmt.Sprintf("%s%c%s%s%s%s%s%s%s%s%d%s%d\n",
-		info.GetStatus(), prefixChar,
-		info.name,
-		FieldSep, info.created.Format(time.RFC3339Nano),
-		FieldSep, info.accessed.Format(time.RFC3339Nano),
-		FieldSep, info.modified.Format(time.RFC3339Nano),
-		FieldSep, info.size,
-		FieldSep, info.crc)
+	var line strings.Builder
+
+	fmt.Fprintf(&line, "%s%c%s", info.GetStatus(), prefixChar, EscapeField(info.name))
+
+	for _, field := range [][2]string{
+		{fieldCreated, info.created.Format(time.RFC3339Nano)},
+		{fieldAccessed, info.accessed.Format(time.RFC3339Nano)},
+		{fieldModified, info.modified.Format(time.RFC3339Nano)},
+		{fieldChanged, info.changed.Format(time.RFC3339Nano)},
+		{fieldTimes, info.times.String()},
+		{fieldSize, strconv.FormatInt(info.size, 10)},
+		{fieldHash, info.GetDigest()},
+		{fieldSampled, info.sampleCode()},
+	} {
+		fmt.Fprintf(&line, "%s%s%s%s", FieldSep, field[0], fieldSep, field[1])
+	}
+
+	if len(info.movedFrom) > 0 {
+		fmt.Fprintf(&line, "%s%s%s%s", FieldSep, fieldFrom, fieldSep, EscapeField(info.movedFrom))
+	}
+
+	// The attributes are only written when the platform keeps them
+	if info.attrs.valid.Has(AttrPerm) {
+		fmt.Fprintf(&line, "%s%s%s%04o", FieldSep, fieldPerm, fieldSep, info.attrs.perm)
+
+		if info.attrs.IsLink() {
+			fmt.Fprintf(&line, "%s%s%s%s", FieldSep, fieldTarget, fieldSep, EscapeField(info.attrs.target))
+		}
+	}
+
+	if info.attrs.valid.Has(AttrOwner) {
+		fmt.Fprintf(&line, "%s%s%s%d%s%s%s%d", FieldSep, fieldUid, fieldSep, info.attrs.uid, FieldSep, fieldGid, fieldSep, info.attrs.gid)
+	}
+
+	if info.attrs.valid.Has(AttrInode) {
+		fmt.Fprintf(&line, "%s%s%s%d%s%s%s%d%s%s%s%d", FieldSep, fieldInode, fieldSep, info.attrs.inode,
+			FieldSep, fieldDevice, fieldSep, info.attrs.device, FieldSep, fieldLinks, fieldSep, info.attrs.links)
+	}
+
+	line.WriteString("\n")
+
+	return line.String()
+}
+
+// sampleCode Get the code for how the CRC was computed
+func (info *FileInfo) sampleCode() string {
+	if info.sampled {
+		return codeSampled
+	}
+
+	return codeMissing
 }
 
 func (info *FileInfo) Display(log *os.File) {
-	fmt.Fprintf(log, "File name: %s\nFlags: '%s', Size: %s, CRC: %d\nCreated: %s, Modified: %s, Accessed: %s\n",
-		info.name, info.GetStatus(), NiceInt64(info.size), info.crc,
-		info.created.Format(time.RFC3339Nano), info.modified.Format(time.RFC3339Nano), info.accessed.Format(time.RFC3339Nano))
+	fmt.Fprintf(log, "File name: %s\nFlags: '%s', Size: %s, Hash: %s%s%s%s\nCreated: %s, Modified: %s, Accessed: %s, Changed: %s, Times: '%s'\n",
+		info.name, info.GetStatus(), NiceInt64(info.size), info.GetDigest(), sampledNote(info.sampled), info.attrs, movedNote(info.movedFrom),
+		info.created.Format(time.RFC3339Nano), info.modified.Format(time.RFC3339Nano), info.accessed.Format(time.RFC3339Nano),
+		info.changed.Format(time.RFC3339Nano), info.times)
+}
+
+// movedNote Note the previous name of a moved file for the display
+func movedNote(from string) string {
+	if len(from) > 0 {
+		return ", Moved from: " + from
+	}
+
+	return ""
+}
+
+// sampledNote Note a sampled CRC for the display
+func sampledNote(sampled bool) string {
+	if sampled {
+		return " (sampled)"
+	}
+
+	return ""
 }
 
 // parseCRCLine Parse a line of file status into a CRCInfo
@@ -121,10 +330,20 @@ func (info *FileInfo) ParseCRCLine(line string) error {
 	// Remove trailing newline if there
 	line = strings.TrimSuffix(line, "\n")
 
+	if len(line) <= prefixSize || line[prefixSize] != prefixChar {
+		return fmt.Errorf("the line '%s' is invalid", line)
+	}
+
 	// Split the string first
 	parts := strings.Split(line[prefixSize+1:], FieldSep)
 
-	if len(parts) != 6 {
+	// Version 2 lines have key=value fields, a time never holds the separator
+	if len(parts) > 1 && strings.Contains(parts[1], fieldSep) {
+		return info.parseFields(line, parts)
+	}
+
+	// Version 1 lines have positional fields, lines written before the changed time was added have 6 parts, and before sampling 8
+	if len(parts) != 6 && len(parts) != 8 && len(parts) != 9 {
 		return fmt.Errorf("the line '%s' is invalid", line)
 	}
 
@@ -159,12 +378,34 @@ func (info *FileInfo) ParseCRCLine(line string) error {
 		return err
 	}
 
-	// Parse the CRC64
+	// Parse the CRC64 or the digest
 	part++
-	if info.crc, err = strconv.ParseUint(parts[part], 10, 64); err != nil {
+	if info.algorithm, info.crc, info.digest, err = parseDigest(parts[part]); err != nil {
 		return err
 	}
 
+	// Parse the changed date/time and the meaningful times
+	info.changed = time.Time{}
+	info.times = TimesLegacy
+
+	if len(parts) >= 8 {
+		part++
+		if info.changed, err = time.Parse(time.RFC3339Nano, parts[part]); err != nil {
+			return err
+		}
+
+		part++
+		info.times = ParseTimeFields(parts[part])
+	}
+
+	// Parse how the CRC was computed
+	info.sampled = false
+
+	if len(parts) == 9 {
+		part++
+		info.sampled = parts[part] == codeSampled
+	}
+
 	// Set the flag
 	info.BuildFlag(line[0:prefixSize])
 
@@ -172,6 +413,177 @@ func (info *FileInfo) ParseCRCLine(line string) error {
 	return nil
 }
 
+// parseFields Parse the name and the key=value fields of a version 2 line
+func (info *FileInfo) parseFields(line string, parts []string) error {
+	fields := make(map[string]string, len(parts))
+
+	for _, part := range parts[1:] {
+		key, value, found := strings.Cut(part, fieldSep)
+
+		if !found {
+			return fmt.Errorf("the field '%s' of the line '%s' is invalid", part, line)
+		}
+
+		fields[key] = value
+	}
+
+	for _, key := range requiredFields {
+		if _, found := fields[key]; !found {
+			return fmt.Errorf("the line '%s' has no %s", line, key)
+		}
+	}
+
+	var err error = nil
+
+	if info.name, err = UnescapeField(parts[0]); err != nil {
+		return fmt.Errorf("the name of the line '%s' is invalid: %s", line, err)
+	}
+
+	for _, field := range []struct {
+		key   string
+		value *time.Time
+	}{{fieldCreated, &info.created}, {fieldAccessed, &info.accessed}, {fieldModified, &info.modified}, {fieldChanged, &info.changed}} {
+		*field.value = time.Time{}
+
+		if text, found := fields[field.key]; found {
+			if *field.value, err = time.Parse(time.RFC3339Nano, text); err != nil {
+				return err
+			}
+		}
+	}
+
+	info.times = ParseTimeFields(fields[fieldTimes])
+
+	if info.size, err = strconv.ParseInt(fields[fieldSize], 10, 64); err != nil {
+		return err
+	}
+
+	if info.algorithm, info.crc, info.digest, err = parseDigest(fields[fieldHash]); err != nil {
+		return err
+	}
+
+	info.sampled = fields[fieldSampled] == codeSampled
+
+	if info.attrs, err = parseAttrs(fields); err != nil {
+		return fmt.Errorf("the line '%s' is invalid: %s", line, err)
+	}
+
+	if info.movedFrom, err = UnescapeField(fields[fieldFrom]); err != nil {
+		return fmt.Errorf("the line '%s' is invalid: %s", line, err)
+	}
+
+	// Set the flag
+	info.BuildFlag(line[0:prefixSize])
+
+	return nil
+}
+
+// parseAttrs Parse the attributes in the fields of a line, the ones missing were not kept by the platform
+func parseAttrs(fields map[string]string) (FileAttrs, error) {
+	attrs := FileAttrs{}
+	var err error = nil
+
+	if text, found := fields[fieldPerm]; found {
+		var perm uint64
+
+		if perm, err = strconv.ParseUint(text, 8, 32); err != nil {
+			return attrs, err
+		}
+
+		attrs.perm = uint32(perm)
+		attrs.valid |= AttrPerm
+
+		if attrs.target, err = UnescapeField(fields[fieldTarget]); err != nil {
+			return attrs, err
+		}
+	}
+
+	if uidText, found := fields[fieldUid]; found {
+		var uid, gid uint64
+
+		if uid, err = strconv.ParseUint(uidText, 10, 32); err != nil {
+			return attrs, err
+		}
+
+		if gid, err = strconv.ParseUint(fields[fieldGid], 10, 32); err != nil {
+			return attrs, err
+		}
+
+		attrs.uid, attrs.gid = uint32(uid), uint32(gid)
+		attrs.valid |= AttrOwner
+	}
+
+	if inodeText, found := fields[fieldInode]; found {
+		if attrs.inode, err = strconv.ParseUint(inodeText, 10, 64); err != nil {
+			return attrs, err
+		}
+
+		if attrs.device, err = strconv.ParseUint(fields[fieldDevice], 10, 64); err != nil {
+			return attrs, err
+		}
+
+		if attrs.links, err = strconv.ParseUint(fields[fieldLinks], 10, 64); err != nil {
+			return attrs, err
+		}
+
+		attrs.valid |= AttrInode
+	}
+
+	return attrs, nil
+}
+
+// EscapeField Escape the bytes that would break a record or a header line, the escape character, the field separator
+// and the line ends, as %XX so a name of any bytes is written on a single line
+func EscapeField(value string) string {
+	if !strings.ContainsAny(value, string(escapeChar)+FieldSep+"\r\n") {
+		return value
+	}
+
+	var escaped strings.Builder
+
+	for index := 0; index < len(value); index++ {
+		switch char := value[index]; char {
+		case escapeChar, FieldSep[0], '\r', '\n':
+			fmt.Fprintf(&escaped, "%c%02X", escapeChar, char)
+		default:
+			escaped.WriteByte(char)
+		}
+	}
+
+	return escaped.String()
+}
+
+// UnescapeField Restore the bytes escaped by EscapeField
+func UnescapeField(value string) (string, error) {
+	if !strings.ContainsRune(value, escapeChar) {
+		return value, nil
+	}
+
+	var unescaped strings.Builder
+
+	for index := 0; index < len(value); index++ {
+		if value[index] != escapeChar {
+			unescaped.WriteByte(value[index])
+			continue
+		}
+
+		if index+2 >= len(value) {
+			return "", fmt.Errorf("the escape at %d of '%s' is incomplete", index, value)
+		}
+
+		char, err := strconv.ParseUint(value[index+1:index+3], 16, 8)
+
+		if err != nil {
+			return "", fmt.Errorf("the escape at %d of '%s' is invalid", index, value)
+		}
+
+		unescaped.WriteByte(byte(char))
+		index += 2
+	}
+
+	return unescaped.String(), nil
+}
+
 func (info *FileInfo) SetMismatched() {
 	info.flag |= flagMismatched
 }
@@ -184,6 +596,16 @@ func (info *FileInfo) SetAdded() {
 	info.flag |= flagInserted
 }
 
+func (info *FileInfo) SetDeleted() {
+	info.flag |= flagDeleted
+}
+
+// SetMoved Flag the file as moved or renamed from a previous name, rather than new
+func (info *FileInfo) SetMoved(from string) {
+	info.flag = info.flag&^flagInserted | flagMoved
+	info.movedFrom = from
+}
+
 func (info *FileInfo) IsSuspicious() bool {
 	return info.flag&flagSuspicious != 0
 }
@@ -196,6 +618,14 @@ func (info *FileInfo) IsAdded() bool {
 	return info.flag&flagInserted != 0
 }
 
+func (info *FileInfo) IsDeleted() bool {
+	return info.flag&flagDeleted != 0
+}
+
+func (info *FileInfo) IsMoved() bool {
+	return info.flag&flagMoved != 0
+}
+
 func (info *FileInfo) ClearFlag() {
 	info.flag = 0x00
 }
@@ -217,6 +647,10 @@ func (info *FileInfo) GetStatus() string {
 
 	if info.flag&flagInserted > 0 {
 		status += codeInserted
+	} else if info.flag&flagDeleted > 0 {
+		status += codeDeleted
+	} else if info.flag&flagMoved > 0 {
+		status += codeMoved
 	} else {
 		status += codeMissing
 	}
@@ -236,7 +670,13 @@ func (info *FileInfo) BuildFlag(prefix string) {
 		info.flag |= flagMismatched
 	}
 
-	if string(prefix[2]) != codeMissing {
+	switch string(prefix[2]) {
+	case codeMissing:
+	case codeDeleted:
+		info.flag |= flagDeleted
+	case codeMoved:
+		info.flag |= flagMoved
+	default:
 		info.flag |= flagInserted
 	}
 }
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"fmt"
+	"hash/crc64"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -42,3 +44,236 @@ func TestBasic(t *testing.T) {
 	info2.ClearFlag()
 	a.True(info2.flag == 0x00)
 }
+
+func TestTimeFields(t *testing.T) {
+	a := assert.New(t)
+
+	// A line written before the changed time was added has the created, accessed and modified times
+	legacy := FileInfo{}
+	err := legacy.ParseCRCLine("---:rec1|2021-01-02T03:04:05Z|2021-01-02T03:04:06Z|2021-01-02T03:04:07Z|10|12345")
+	a.Nil(err)
+	a.Equal(TimesLegacy, legacy.GetTimeFields())
+	a.True(legacy.GetChanged().IsZero())
+
+	// A file system without a created time
+	info := FileInfo{}
+	info.name = "rec1"
+	info.created = legacy.changed
+	info.accessed = legacy.accessed
+	info.modified = legacy.modified
+	info.changed = time.Now()
+	info.times = TimeAccessed | TimeModified | TimeChanged
+	info.size = 10
+	info.crc = 12345
+
+	line := info.BuildCRCLine()
+	a.Contains(line, "|times=-AMH|")
+	a.True(strings.HasSuffix(line, "|sampled=-\n"))
+
+	info2 := FileInfo{}
+	a.Nil(info2.ParseCRCLine(line))
+	a.Equal(info.times, info2.times)
+	a.True(info.changed.Equal(info2.changed))
+
+	// Only the times kept by both are compared
+	a.True(info.TimesEqual(legacy))
+	info2.modified = time.Now()
+	a.False(info2.TimesEqual(legacy))
+	a.False(info.TimesEqual(FileInfo{}))
+}
+
+func TestSampled(t *testing.T) {
+	a := assert.New(t)
+
+	info := FileInfo{}
+	info.name = "big.log"
+	info.times = TimeModified
+	info.size = 10000000
+	info.crc = 12345
+	info.SetSampled(true)
+
+	line := info.BuildCRCLine()
+	a.True(strings.HasSuffix(line, "|sampled=S\n"))
+
+	info2 := FileInfo{}
+	a.Nil(info2.ParseCRCLine(line))
+	a.True(info2.IsSampled())
+	a.True(info.IsEqual(info2))
+
+	// A full CRC can't be compared with a sampled one, so the contents may have changed
+	info2.SetSampled(false)
+	info2.crc = 54321
+	a.False(info.CRCComparable(info2))
+	a.False(info.IsEqual(info2))
+	a.True(info.MetadataEqual(info2))
+}
+
+func TestDigests(t *testing.T) {
+	a := assert.New(t)
+
+	content := []byte("The quick brown fox jumps over the lazy dog")
+
+	for _, algorithm := range []string{HashCRC64, HashSHA256, HashBLAKE2b, HashXXHash} {
+		hash, err := NewHash(algorithm)
+		a.Nil(err)
+		hash.Write(content)
+
+		info := FileInfo{}
+		info.name = "fox.txt"
+		info.times = TimeModified
+		info.size = int64(len(content))
+		info.SetDigest(algorithm, hash.Sum(nil))
+		a.Equal(algorithm, info.GetAlgorithm())
+
+		info2 := FileInfo{}
+		a.Nil(info2.ParseCRCLine(info.BuildCRCLine()))
+		a.Equal(algorithm, info2.GetAlgorithm())
+		a.Equal(info.GetDigest(), info2.GetDigest())
+		a.True(info.DigestEqual(info2))
+		a.True(info.IsEqual(info2))
+	}
+
+	// A CRC64 is still written as a number
+	hash, _ := NewHash(HashCRC64)
+	hash.Write(content)
+	info := FileInfo{}
+	info.SetDigest(HashCRC64, hash.Sum(nil))
+	a.Equal(crc64.Checksum(content, crc64.MakeTable(crc64.ECMA)), info.GetCRC())
+
+	// Digests of different algorithms are never equal
+	sha, _ := NewHash(HashSHA256)
+	sha.Write(content)
+	info2 := FileInfo{}
+	info2.SetDigest(HashSHA256, sha.Sum(nil))
+	a.False(info.DigestEqual(info2))
+	a.False(info.CRCComparable(info2))
+
+	_, err := NewHash("md5")
+	a.NotNil(err)
+	a.NotNil(info2.ParseCRCLine("---:a|2024-01-01T00:00:00Z|2024-01-01T00:00:00Z|2024-01-01T00:00:00Z|1|md5:00"))
+	a.NotNil(info2.ParseCRCLine("---:a|2024-01-01T00:00:00Z|2024-01-01T00:00:00Z|2024-01-01T00:00:00Z|1|sha256:xyz"))
+}
+
+func TestEscapedNames(t *testing.T) {
+	a := assert.New(t)
+
+	for _, name := range []string{"/data/a|b.txt", "/data/line\nbreak", "/data/cr\r", "/data/100%.txt", "/data/%7C", "/data/\xff\xfe", "plain"} {
+		info := FileInfo{}
+		info.name = name
+		info.times = TimeModified
+		info.size = 1
+
+		line := info.BuildCRCLine()
+		a.Equal(1, strings.Count(line, "\n"))
+		a.False(strings.Contains(line, "\r"))
+
+		info2 := FileInfo{}
+		a.Nil(info2.ParseCRCLine(line))
+		a.Equal(name, info2.GetName())
+	}
+
+	// Escapes must be complete hex bytes
+	for _, value := range []string{"a%", "a%7", "a%zz", "%-1"} {
+		_, err := UnescapeField(value)
+		a.NotNil(err)
+	}
+}
+
+func FuzzParseCRCLine(f *testing.F) {
+	f.Add("---:rec1|2021-01-02T03:04:05Z|2021-01-02T03:04:06Z|2021-01-02T03:04:07Z|10|12345")
+	f.Add("-M-:rec1|2021-01-02T03:04:05Z|2021-01-02T03:04:06Z|2021-01-02T03:04:07Z|10|12345|2021-01-02T03:04:08Z|CAMH|S")
+	f.Add("SMN:a%7Cb|created=2021-01-02T03:04:05Z|accessed=2021-01-02T03:04:06Z|modified=2021-01-02T03:04:07Z|" +
+		"changed=2021-01-02T03:04:08Z|times=-AMH|size=10|hash=sha256:00ff|sampled=S")
+	f.Add("--")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		info := FileInfo{}
+
+		if info.ParseCRCLine(line) != nil {
+			return
+		}
+
+		// Whatever parses is written back as one line that parses to the same record
+		built := info.BuildCRCLine()
+
+		if strings.Count(built, "\n") != 1 || !strings.HasSuffix(built, "\n") {
+			t.Fatalf("the record of '%s' is not a single line: %q", line, built)
+		}
+
+		info2 := FileInfo{}
+
+		if err := info2.ParseCRCLine(built); err != nil {
+			t.Fatalf("the record %q of '%s' doesn't parse: %s", built, line, err)
+		}
+
+		if info2.GetName() != info.GetName() || info2.BuildCRCLine() != built {
+			t.Fatalf("the record %q of '%s' doesn't round trip", built, line)
+		}
+	})
+}
+
+func FuzzNames(f *testing.F) {
+	for _, name := range []string{"plain", "a|b", "line\nbreak", "cr\r", "100%", "%7C", "\xff"} {
+		f.Add(name)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		info := FileInfo{}
+		info.name = name
+		info.times = TimeModified
+
+		info2 := FileInfo{}
+
+		if err := info2.ParseCRCLine(info.BuildCRCLine()); err != nil {
+			t.Fatalf("the record of %q doesn't parse: %s", name, err)
+		}
+
+		if info2.GetName() != name {
+			t.Fatalf("the name %q was read back as %q", name, info2.GetName())
+		}
+
+		if unescaped, err := UnescapeField(EscapeField(name)); err != nil || unescaped != name {
+			t.Fatalf("the name %q doesn't round trip: %q %v", name, unescaped, err)
+		}
+	})
+}
+
+func TestDeleted(t *testing.T) {
+	a := assert.New(t)
+
+	info := FileInfo{}
+	info.name = "gone.txt"
+	info.times = TimeModified
+	info.SetSuspicious()
+	info.SetDeleted()
+	a.Equal("S-D", info.GetStatus())
+
+	info2 := FileInfo{}
+	a.Nil(info2.ParseCRCLine(info.BuildCRCLine()))
+	a.True(info2.IsDeleted())
+	a.True(info2.IsSuspicious())
+	a.False(info2.IsAdded())
+
+	// A new file is never deleted
+	info2.ClearFlag()
+	info2.BuildFlag("--N")
+	a.True(info2.IsAdded())
+	a.False(info2.IsDeleted())
+}
+
+func TestMoved(t *testing.T) {
+	a := assert.New(t)
+
+	info := FileInfo{}
+	info.name = "/new/a|b.txt"
+	info.times = TimeModified
+	info.SetAdded()
+	info.SetMoved("/old/a|b.txt")
+	a.Equal("--R", info.GetStatus())
+	a.False(info.IsAdded())
+
+	info2 := FileInfo{}
+	a.Nil(info2.ParseCRCLine(info.BuildCRCLine()))
+	a.True(info2.IsMoved())
+	a.Equal("/old/a|b.txt", info2.GetMovedFrom())
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"runtime"
+	"strings"
+)
+
+// FileKey Get the key identifying a file by its name.  The names are only folded to lower case on
+// the platforms whose file systems ignore the case, on Linux Index.cfm and index.cfm are different files
+func FileKey(name string) string {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(name)
+	}
+
+	return name
+}
@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileKey(t *testing.T) {
+	a := assert.New(t)
+
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		a.Equal("c:/web/index.cfm", FileKey("C:/Web/Index.cfm"))
+	} else {
+		a.Equal("/web/Index.cfm", FileKey("/web/Index.cfm"))
+		a.NotEqual(FileKey("/web/index.cfm"), FileKey("/web/Index.cfm"))
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"time"
+)
+
+// TimeFields A set of file times, used to record which of them the platform really keeps
+type TimeFields byte
+
+const (
+	TimeCreated  TimeFields = 0x01 // Creation (birth) time
+	TimeAccessed TimeFields = 0x02 // Last accessed time
+	TimeModified TimeFields = 0x04 // Last modified (written) time
+	TimeChanged  TimeFields = 0x08 // Last status change time (ctime)
+
+	// Times recorded by the baselines written before the changed time was added
+	TimesLegacy = TimeCreated | TimeAccessed | TimeModified
+
+	codeCreated  = "C" // Code for a meaningful created time
+	codeAccessed = "A" // Code for a meaningful accessed time
+	codeModified = "M" // Code for a meaningful modified time
+	codeChanged  = "H" // Code for a meaningful changed time
+)
+
+// Times of a file as the platform reports them
+type FileTimes struct {
+	created  time.Time  // Created time, the changed time when the file system has no birth time
+	accessed time.Time  // Last accessed time
+	modified time.Time  // Last modified time
+	changed  time.Time  // Last status change time
+	valid    TimeFields // The times that are meaningful on this platform and file system
+}
+
+func (times *FileTimes) GetCreated() time.Time {
+	return times.created
+}
+
+func (times *FileTimes) GetAccessed() time.Time {
+	return times.accessed
+}
+
+func (times *FileTimes) GetModified() time.Time {
+	return times.modified
+}
+
+func (times *FileTimes) GetChanged() time.Time {
+	return times.changed
+}
+
+func (times *FileTimes) GetValid() TimeFields {
+	return times.valid
+}
+
+// Has Check if the times are meaningful
+func (fields TimeFields) Has(times TimeFields) bool {
+	return fields&times == times
+}
+
+// String Build the codes for the meaningful times, i.e. "-AMH" when there is no created time
+func (fields TimeFields) String() string {
+	codes := ""
+
+	for _, field := range []struct {
+		time TimeFields
+		code string
+	}{{TimeCreated, codeCreated}, {TimeAccessed, codeAccessed}, {TimeModified, codeModified}, {TimeChanged, codeChanged}} {
+		if fields.Has(field.time) {
+			codes += field.code
+		} else {
+			codes += codeMissing
+		}
+	}
+
+	return codes
+}
+
+// ParseTimeFields Get the meaningful times from their codes
+func ParseTimeFields(codes string) TimeFields {
+	var fields TimeFields
+
+	for _, code := range codes {
+		switch string(code) {
+		case codeCreated:
+			fields |= TimeCreated
+		case codeAccessed:
+			fields |= TimeAccessed
+		case codeModified:
+			fields |= TimeModified
+		case codeChanged:
+			fields |= TimeChanged
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// GetFileTimes Get the times of a file with statx, which has the birth time when the file system keeps it.
+// Without a birth time, or on kernels before statx, the created time is the status change time
+// path: Full path name of the file
+// info: The stat info of the file
+func GetFileTimes(path string, info os.FileInfo) (FileTimes, error) {
+	times := FileTimes{modified: info.ModTime(), valid: TimeModified}
+
+	// The times of a link itself when the stat info is of the link
+	flags := unix.AT_STATX_SYNC_AS_STAT
+	if info.Mode()&os.ModeSymlink != 0 {
+		flags |= unix.AT_SYMLINK_NOFOLLOW
+	}
+
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, path, flags, unix.STATX_BASIC_STATS|unix.STATX_BTIME, &stx)
+
+	if err == nil {
+		times.accessed = statxTime(stx.Atime)
+		times.modified = statxTime(stx.Mtime)
+		times.changed = statxTime(stx.Ctime)
+		times.created = times.changed
+		times.valid = TimeAccessed | TimeModified | TimeChanged
+
+		// Some file systems report a birth time of zero rather than none
+		if stx.Mask&unix.STATX_BTIME != 0 && (stx.Btime.Sec != 0 || stx.Btime.Nsec != 0) {
+			times.created = statxTime(stx.Btime)
+			times.valid |= TimeCreated
+		}
+
+		return times, nil
+	}
+
+	// Fall back to the stat info on kernels without statx, or where a sandbox blocks it
+	if !errors.Is(err, unix.ENOSYS) && !errors.Is(err, unix.EPERM) {
+		return times, err
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		times.accessed = time.Unix(stat.Atim.Unix())
+		times.changed = time.Unix(stat.Ctim.Unix())
+		times.created = times.changed
+		times.valid |= TimeAccessed | TimeChanged
+	}
+
+	return times, nil
+}
+
+// statxTime Convert a statx timestamp
+func statxTime(stamp unix.StatxTimestamp) time.Time {
+	return time.Unix(stamp.Sec, int64(stamp.Nsec))
+}
+
+// OpenQuietly Open a file for reading without updating its accessed time.  Only the owner (or root) may do that,
+// anyone else gets an ordinary open
+func OpenQuietly(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOATIME, 0)
+
+	if errors.Is(err, os.ErrPermission) {
+		return os.Open(path)
+	}
+
+	return file, err
+}
+
+// RestoreTimes Does nothing, setting the times would change the status change time that is being tracked
+func RestoreTimes(path string, times FileTimes) error {
+	return nil
+}
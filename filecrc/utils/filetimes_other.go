@@ -0,0 +1,24 @@
+//go:build !windows && !linux
+
+package utils
+
+import (
+	"os"
+)
+
+// GetFileTimes Get the times of a file where only the modified time is portable
+// path: Full path name of the file
+// info: The stat info of the file
+func GetFileTimes(path string, info os.FileInfo) (FileTimes, error) {
+	return FileTimes{modified: info.ModTime(), valid: TimeModified}, nil
+}
+
+// OpenQuietly Open a file for reading
+func OpenQuietly(path string) (*os.File, error) {
+	return os.Open(path)
+}
+
+// RestoreTimes Does nothing, setting the times would change the status change time
+func RestoreTimes(path string, times FileTimes) error {
+	return nil
+}
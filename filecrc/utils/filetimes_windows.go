@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// GetFileTimes Get the times of a file from its Windows attributes, Windows has no status change time
+// path: Full path name of the file
+// info: The stat info of the file
+func GetFileTimes(path string, info os.FileInfo) (FileTimes, error) {
+	fs, ok := info.Sys().(*syscall.Win32FileAttributeData)
+
+	if !ok {
+		return FileTimes{modified: info.ModTime(), valid: TimeModified}, nil
+	}
+
+	return FileTimes{
+		created:  time.Unix(0, fs.CreationTime.Nanoseconds()),
+		accessed: time.Unix(0, fs.LastAccessTime.Nanoseconds()),
+		modified: time.Unix(0, fs.LastWriteTime.Nanoseconds()),
+		valid:    TimeCreated | TimeAccessed | TimeModified,
+	}, nil
+}
+
+// OpenQuietly Open a file for reading, Windows doesn't update the accessed time until it's restored
+func OpenQuietly(path string) (*os.File, error) {
+	return os.Open(path)
+}
+
+// RestoreTimes Put back the accessed and modified times changed by reading the file
+func RestoreTimes(path string, times FileTimes) error {
+	return os.Chtimes(path, times.accessed, times.modified)
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"strconv"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Hash algorithms for the file contents
+const (
+	HashCRC64   = "crc64"   // CRC64-ECMA, fast but easy to collide deliberately
+	HashSHA256  = "sha256"  // SHA-256
+	HashBLAKE2b = "blake2b" // BLAKE2b-256, cryptographic and faster than SHA-256
+	HashXXHash  = "xxhash"  // xxHash64, the fastest but not cryptographic
+
+	digestSep = ":" // Separator between the algorithm and the hex digest in a record
+)
+
+// Table for the CRC64 computations
+var crcTable = crc64.MakeTable(crc64.ECMA)
+
+// NewHash Create the hash for an algorithm
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case HashCRC64:
+		return crc64.New(crcTable), nil
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashBLAKE2b:
+		return blake2b.New256(nil)
+	case HashXXHash:
+		return xxhash.New(), nil
+	}
+
+	return nil, fmt.Errorf("the hash algorithm '%s' is not one of %s, %s, %s or %s", algorithm, HashCRC64, HashSHA256, HashBLAKE2b, HashXXHash)
+}
+
+// formatDigest Build the text of a digest for a record.  A CRC64 is written as a number as it always was,
+// the other algorithms as the algorithm name and the hex digest, i.e. sha256:9f86d0...
+func formatDigest(algorithm string, crc uint64, digest string) string {
+	if len(algorithm) == 0 {
+		return strconv.FormatUint(crc, 10)
+	}
+
+	return algorithm + digestSep + digest
+}
+
+// parseDigest Parse the text of a digest from a record
+// returns the algorithm (empty for a CRC64), the CRC64 and the hex digest
+func parseDigest(text string) (string, uint64, string, error) {
+	sepIndex := strings.Index(text, digestSep)
+
+	if sepIndex < 0 {
+		crc, err := strconv.ParseUint(text, 10, 64)
+		return "", crc, "", err
+	}
+
+	algorithm := text[:sepIndex]
+	digest := text[sepIndex+1:]
+
+	if _, err := NewHash(algorithm); err != nil {
+		return "", 0, "", err
+	}
+
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", 0, "", fmt.Errorf("the %s digest '%s' is invalid", algorithm, digest)
+	}
+
+	return algorithm, 0, digest, nil
+}
+
+// crcFromSum Get the CRC64 from the sum of a CRC64 hash
+func crcFromSum(sum []byte) uint64 {
+	return binary.BigEndian.Uint64(sum)
+}
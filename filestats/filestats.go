@@ -6,9 +6,9 @@ import (
 	"io"
 	"os"
 	"strings"
-	"syscall"
 	"time"
 
+	"dacdb.com/GoCode/filecrc/utils"
 	"github.com/dustin/go-humanize"
 	"github.com/pborman/getopt/v2"
 )
@@ -33,11 +33,13 @@ var (
 )
 
 type FileStats struct {
-	accessed string // Last accessed date and time
-	modified string // Last modified date and time
-	created  string // Created date and time
-	size     int64  // File size
-	crc64    uint64 // File CRC
+	accessed string           // Last accessed date and time
+	modified string           // Last modified date and time
+	created  string           // Created date and time
+	changed  string           // Status change date and time
+	times    utils.TimeFields // Times kept by the platform
+	size     int64            // File size
+	crc64    uint64           // File CRC
 }
 
 func main() {
@@ -180,16 +182,19 @@ func getFileInfo(fileName string, buildCRC bool) *FileStats {
 		return nil
 	}
 
-	fs := info.Sys().(*syscall.Win32FileAttributeData)
-	lastAccessed := time.Unix(0, fs.LastAccessTime.Nanoseconds())
-	lastModified := time.Unix(0, fs.LastWriteTime.Nanoseconds())
+	times, err := utils.GetFileTimes(fileName, info)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting the times of file %s: %s\n", fileName, err)
+		return nil
+	}
 
 	// Build the CRC if requested
 
 	var crc uint64
 	if buildCRC {
 		// Open the file and read it to build the CRC
-		fileRdr, err := os.Open(fileName)
+		fileRdr, err := utils.OpenQuietly(fileName)
 
 		if err != nil {
 			return nil
@@ -203,7 +208,7 @@ func getFileInfo(fileName string, buildCRC bool) *FileStats {
 
 		// Close the file and reset the last accessed date/time
 		fileRdr.Close()
-		os.Chtimes(fileName, lastAccessed, lastModified)
+		utils.RestoreTimes(fileName, times)
 
 		// Return if read error
 		if err != nil {
@@ -219,9 +224,11 @@ func getFileInfo(fileName string, buildCRC bool) *FileStats {
 	}
 
 	stats := FileStats{}
-	stats.accessed = lastAccessed.Format(time.RFC3339Nano)
-	stats.modified = lastModified.Format(time.RFC3339Nano)
-	stats.created = time.Unix(0, fs.CreationTime.Nanoseconds()).Format(time.RFC3339Nano)
+	stats.accessed = times.GetAccessed().Format(time.RFC3339Nano)
+	stats.modified = times.GetModified().Format(time.RFC3339Nano)
+	stats.created = times.GetCreated().Format(time.RFC3339Nano)
+	stats.changed = times.GetChanged().Format(time.RFC3339Nano)
+	stats.times = times.GetValid()
 	stats.size = info.Size()
 	stats.crc64 = crc
 
@@ -238,18 +245,21 @@ func setFileInfo(fileName string) bool {
 		return false
 	}
 
-	fs := info.Sys().(*syscall.Win32FileAttributeData)
-	lastAccessed := time.Unix(0, fs.LastAccessTime.Nanoseconds())
-	lastModified := time.Unix(0, fs.LastWriteTime.Nanoseconds())
+	times, err := utils.GetFileTimes(fileName, info)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting the times of file %s: %s\n", fileName, err)
+		return false
+	}
 
 	if len(parmAccessSpec) == 0 {
 		// Use files current value if not specified
-		parmAccessTime = lastAccessed
+		parmAccessTime = times.GetAccessed()
 	}
 
 	if len(parmModSpec) == 0 {
 		// Use files current value if not specified
-		parmModTime = lastModified
+		parmModTime = times.GetModified()
 	}
 
 	// Change the times
@@ -267,12 +277,22 @@ func setFileInfo(fileName string) bool {
 func printStats(stats *FileStats) {
 	fmt.Fprintf(os.Stdout, "\n")
 	fmt.Fprintf(os.Stdout, "Information for file: %s\n", parmFileName)
-	fmt.Fprintf(os.Stdout, "Created:  %s\n", stats.created)
-	fmt.Fprintf(os.Stdout, "Modified: %s\n", stats.modified)
-	fmt.Fprintf(os.Stdout, "Accessed: %s\n", stats.accessed)
+	fmt.Fprintf(os.Stdout, "Created:  %s%s\n", stats.created, timeNote(stats.times, utils.TimeCreated))
+	fmt.Fprintf(os.Stdout, "Modified: %s%s\n", stats.modified, timeNote(stats.times, utils.TimeModified))
+	fmt.Fprintf(os.Stdout, "Accessed: %s%s\n", stats.accessed, timeNote(stats.times, utils.TimeAccessed))
+	fmt.Fprintf(os.Stdout, "Changed:  %s%s\n", stats.changed, timeNote(stats.times, utils.TimeChanged))
 	fmt.Fprintf(os.Stdout, "Size:     %s\n", humanize.Comma(int64(stats.size)))
 
 	if stats.crc64 != 0 {
 		fmt.Fprintf(os.Stdout, "CRC64:    %d\n", stats.crc64)
 	}
 }
+
+// timeNote Note the times the platform doesn't keep
+func timeNote(times utils.TimeFields, field utils.TimeFields) string {
+	if times.Has(field) {
+		return ""
+	}
+
+	return " (not kept on this platform)"
+}